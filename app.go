@@ -3,16 +3,14 @@ package main
 import (
 	"bytes"
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"crypto/sha256"	
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"io/fs"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime" // Standard Library (untuk NumCPU)
@@ -23,37 +21,62 @@ import (
 	"time"
 	"unicode"
 
-	"github.com/disintegration/imaging"
 	"github.com/glebarez/sqlite"
 	
 	// [FIX] Kita alias ini jadi 'wailsRuntime' supaya tidak bentrok dengan 'runtime' asli
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime" 
 	
-	_ "golang.org/x/image/webp"
 	"gorm.io/gorm"
 )
 
-var EncryptionKey = []byte("GalleryVault_SecureKey_2026_IDN!")
-
-const (
-	maxWidth    = 1920
-	jpegQuality = 75
-	coverWidth  = 300
-)
-
 // Job struct untuk worker import
 type ImportJob struct {
-	Path       string
+	Page       importPage
+	Profile    string // profil penyimpanan (lihat storage.go)
+	Settings   ImageSettings
 	DestPath   string
-	ResultChan chan<- bool
+	Key        []byte
+	ResultChan chan<- importResult
 }
 
 type App struct {
 	ctx              context.Context
-	db               *gorm.DB
+	db               *gorm.DB // library (in-memory, lihat library.go)
+	configDB         *gorm.DB // library.db: GlobalConfig + audit log (plaintext)
+	appDataDir       string
 	vaultDir         string
 	hiddenModeActive bool
-	unlockedBooks    map[string]bool
+	decoyActive      bool // profil decoy yang terbuka (lihat decoy.go)
+	bookKeys         map[uint][]byte // buku terkunci yang sedang dibuka (ID -> DEK buku)
+
+	// Kunci vault (DEK) hanya ada di memori selama sesi terbuka
+	attemptMu sync.Mutex
+	auditMu   sync.Mutex
+
+	keyMu      sync.RWMutex
+	vaultKey   []byte
+	retiredKey []byte // DEK lama, selama rotasi berjalan / ada file yang gagal dirotasi
+	rotating   bool
+	// Rotasi di background (lihat rotation.go): dibatalkan saat sesi dikunci
+	rotationCancel context.CancelFunc
+	rotationDone   chan struct{}
+
+	// State library terenkripsi
+	libMu       sync.RWMutex
+	libraryOpen bool
+	flushMu     sync.Mutex
+	flushTimer  *time.Timer
+	writeMu     sync.Mutex
+
+	// Auto-lock (lihat session.go)
+	sessionMu    sync.Mutex
+	idleTimer    *time.Timer
+	lastActivity time.Time
+	sessionHolds int
+	// Job import di background (lihat importjob.go)
+	importMu    sync.Mutex
+	importJobs  map[string]context.CancelFunc
+	importRunMu sync.Mutex // job dijalankan satu per satu
 }
 
 // [BARU] Struct untuk Filter Pencarian dari Frontend
@@ -69,7 +92,8 @@ type SearchQuery struct {
 
 func NewApp() *App {
 	return &App{
-		unlockedBooks: make(map[string]bool),
+		bookKeys:   make(map[uint][]byte),
+		importJobs: make(map[string]context.CancelFunc),
 	}
 }
 
@@ -80,17 +104,39 @@ func (a *App) startup(ctx context.Context) {
 		log.Fatal(err)
 	}
 
-	appDataDir := filepath.Join(userConfigDir, "GalleryVault")
-	a.vaultDir = filepath.Join(appDataDir, "vault")
-	os.MkdirAll(a.vaultDir, 0755)
+	a.appDataDir = filepath.Join(userConfigDir, "GalleryVault")
+	a.useProfile(false) // profil decoy dipilih saat VerifyPassword
 
-	dbPath := filepath.Join(appDataDir, "library.db")
+	dbPath := filepath.Join(a.appDataDir, "library.db")
 	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
 	if err != nil {
 		log.Fatal("Gagal koneksi database:", err)
 	}
-	a.db = db
-a.db.AutoMigrate(&GlobalConfig{}, &Book{}, &Tag{}, &Series{})
+	a.configDB = db
+	a.configDB.AutoMigrate(&GlobalConfig{}, &AuditEntry{})
+	a.migrateDecoyNames()
+	a.purgePlainThumbnails()
+
+	// Library asli baru dibuka setelah VerifyPassword (lihat openLibrary)
+	a.db, err = openMemoryDB()
+	if err != nil {
+		log.Fatal("Gagal membuat database:", err)
+	}
+}
+
+// emit mengirim event ke frontend (diabaikan sebelum startup selesai).
+func (a *App) emit(name string, data ...interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	wailsRuntime.EventsEmit(a.ctx, name, data...)
+}
+
+// shutdown menyimpan perubahan library terakhir sebelum aplikasi ditutup.
+func (a *App) shutdown(ctx context.Context) {
+	if err := a.flushLibrary(); err != nil {
+		log.Printf("library: gagal menyimpan: %v", err)
+	}
 }
 
 // --- CONFIG & SECURITY ---
@@ -100,262 +146,574 @@ func HashString(s string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-func EncryptData(plain []byte) ([]byte, error) {
-	block, _ := aes.NewCipher(EncryptionKey)
-	gcm, _ := cipher.NewGCM(block)
-	nonce := make([]byte, gcm.NonceSize())
-	io.ReadFull(rand.Reader, nonce)
-	return gcm.Seal(nonce, nonce, plain, nil), nil
-}
-
-func TryDecryptData(data []byte) []byte {
-	if len(data) < 16 {
-		return data
-	}
-	block, _ := aes.NewCipher(EncryptionKey)
-	gcm, _ := cipher.NewGCM(block)
-	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
-	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return data
-	}
-	return plain
-}
-
 func (a *App) getConfig(key string) string {
 	var conf GlobalConfig
-	if a.db.First(&conf, "key = ?", key).Error != nil {
+	if a.configDB.First(&conf, "key = ?", key).Error != nil {
 		return ""
 	}
 	return conf.Value
 }
 
 func (a *App) setConfig(key, value string) {
+	setConfigTx(a.configDB, key, value)
+}
+
+func setConfigTx(tx *gorm.DB, key, value string) error {
 	var conf GlobalConfig
-	a.db.Where(GlobalConfig{Key: key}).Assign(GlobalConfig{Value: value}).FirstOrCreate(&conf)
+	return tx.Where(GlobalConfig{Key: key}).Assign(GlobalConfig{Value: value}).FirstOrCreate(&conf).Error
+}
+
+func (a *App) deleteConfig(key string) {
+	a.configDB.Where("key = ?", key).Delete(&GlobalConfig{})
+}
+
+// checkConfigPassword memverifikasi password yang hash-nya ada di GlobalConfig,
+// lengkap dengan throttling dan upgrade hash lama.
+func (a *App) checkConfigPassword(scope, configKey, p string) bool {
+	if a.retryAfter(scope) > 0 {
+		return false
+	}
+	ok, upgrade := CheckPassword(p, a.getConfig(configKey))
+	a.recordAttempt(scope, ok)
+	if ok && upgrade {
+		a.setConfig(configKey, HashPassword(p))
+	}
+	return ok
 }
 
 func (a *App) HasPassword() bool { return a.getConfig("master_hash") != "" }
-func (a *App) VerifyPassword(p string) bool { return HashString(p) == a.getConfig("master_hash") }
-func (a *App) SetMasterPassword(p string) bool {
-	if p == "" {
+
+// VerifyPassword membuka kunci vault: DEK di-unwrap dari master_keyslot
+// dan disimpan di memori untuk sesi ini. Password decoy membuka profil decoy.
+func (a *App) VerifyPassword(p string) bool {
+	ok, decoy := a.checkMasterPassword(p)
+	if !ok {
+		return false
+	}
+	if a.IsVaultUnlocked() && a.decoyActive != decoy {
+		// Pindah profil: tutup sesi yang sedang terbuka dulu
+		a.wipeSession()
+	}
+	a.useProfile(decoy)
+	slot := a.loadKeySlot(a.cfg("master_keyslot"))
+	if slot == nil {
+		// Vault lama (sebelum ada key slot): buat DEK baru sekarang.
+		// File lama tetap terbaca lewat kunci legacy sampai migrasi selesai.
+		return a.initVaultKey(p)
+	}
+	key, err := slot.unwrap(p)
+	if err != nil {
 		return false
 	}
-	a.setConfig("master_hash", HashString(p))
+	return a.unlockSession(p, key)
+}
+
+// unlockSession memasang DEK sebagai kunci sesi, membuka library
+// terenkripsi, lalu melanjutkan rotasi/migrasi yang tertunda.
+func (a *App) unlockSession(p string, key []byte) bool {
+	a.setSessionKey(key)
+	oldKey := a.pendingRotationKey(p)
+	if err := a.openLibrary(); err != nil {
+		log.Printf("library: %v", err)
+		a.setSessionKey(nil)
+		return false
+	}
+	a.checkAuditLog()
+	a.resumeRotation(oldKey, key)
+	a.resumeImports()
+	a.armIdleTimer()
 	return true
 }
 
+// SetMasterPassword membuat password pertama (sekaligus DEK baru), atau
+// membungkus ulang DEK yang sedang terbuka dengan password baru.
+// Saat setup pertama, recovery key (24 kata) dikembalikan untuk dicatat user;
+// saat ganti password hasilnya "".
+func (a *App) SetMasterPassword(p string) (string, error) {
+	if p == "" {
+		return "", fmt.Errorf("password tidak boleh kosong")
+	}
+	if a.HasPassword() {
+		// Ganti password hanya boleh saat vault sedang terbuka
+		key := a.sessionKey()
+		if key == nil {
+			return "", ErrVaultLocked
+		}
+		defer wipeBytes(key)
+		if a.isRotating() {
+			return "", fmt.Errorf("rotasi kunci sedang berjalan")
+		}
+		if a.usedByOtherProfile(p, a.decoyActive) {
+			return "", errPasswordInUse
+		}
+		slot, err := newKeySlot(p, key)
+		if err != nil {
+			return "", err
+		}
+		// Rotasi yang belum tuntas (ada file gagal): DEK lama di
+		// rotation_keyslot ikut dibungkus password baru, kalau tidak file
+		// yang belum dirotasi tidak bisa dibuka lagi setelah unlock berikutnya
+		var rotationSlot *keySlot
+		if a.loadKeySlot(a.cfg("rotation_keyslot")) != nil {
+			a.keyMu.RLock()
+			retired := append([]byte(nil), a.retiredKey...)
+			a.keyMu.RUnlock()
+			defer wipeBytes(retired)
+			if len(retired) == 0 {
+				return "", fmt.Errorf("rotasi kunci sebelumnya belum selesai, kunci lama tidak terbuka")
+			}
+			if rotationSlot, err = newKeySlot(p, retired); err != nil {
+				return "", err
+			}
+		}
+		err = a.configDB.Transaction(func(tx *gorm.DB) error {
+			if rotationSlot != nil {
+				if err := setConfigTx(tx, a.cfg("rotation_keyslot"), marshalKeySlot(rotationSlot)); err != nil {
+					return err
+				}
+			}
+			if err := setConfigTx(tx, a.cfg("master_keyslot"), marshalKeySlot(slot)); err != nil {
+				return err
+			}
+			return setConfigTx(tx, a.cfg("master_hash"), HashPassword(p))
+		})
+		if err != nil {
+			return "", err
+		}
+		a.audit(auditPasswordChange, "master", true, "")
+		return "", nil
+	}
+	if !a.initVaultKey(p) {
+		return "", fmt.Errorf("gagal membuat kunci vault")
+	}
+	a.setConfig("master_hash", HashPassword(p))
+	key := a.sessionKey()
+	recoveryKey, err := a.newRecoveryKey(key)
+	wipeBytes(key)
+	a.audit(auditRecoveryKey, "master", err == nil, auditDetail(err))
+	if err != nil {
+		// Password sudah aktif; recovery key bisa dibuat ulang dari Settings
+		return "", fmt.Errorf("password tersimpan, tapi recovery key gagal dibuat: %w", err)
+	}
+	return recoveryKey, nil
+}
+
+func (a *App) initVaultKey(p string) bool {
+	key, err := newDataKey()
+	if err != nil {
+		return false
+	}
+	slot, err := newKeySlot(p, key)
+	if err != nil {
+		return false
+	}
+	// Vault yang sudah berisi file = file lama dengan kunci legacy,
+	// tandai supaya dimigrasi ke DEK baru.
+	if entries, _ := os.ReadDir(a.vaultDir); len(entries) > 0 {
+		a.setConfig(a.cfg("legacy_vault"), "1")
+	}
+	a.saveKeySlot(a.cfg("master_keyslot"), slot)
+	return a.unlockSession(p, key)
+}
+
 func (a *App) SetHiddenZonePassword(p string) bool {
 	if p == "" {
 		return false
 	}
-	a.setConfig("hidden_hash", HashString(p))
+	a.setConfig(a.cfg("hidden_hash"), HashPassword(p))
 	return true
 }
 func (a *App) ToggleHiddenZone(p string) bool {
-	storedHash := a.getConfig("hidden_hash")
+	storedHash := a.getConfig(a.cfg("hidden_hash"))
 	if storedHash == "" {
 		a.SetHiddenZonePassword(p)
+		a.audit(auditHiddenZone, "hidden", true, "password dibuat")
 		a.hiddenModeActive = true
 		return true
 	}
-	if HashString(p) == storedHash {
+	ok := a.checkConfigPassword("hidden", a.cfg("hidden_hash"), p)
+	a.audit(auditHiddenZone, "hidden", ok, "")
+	if ok {
 		a.hiddenModeActive = true
 		return true
 	}
 	return false
 }
-func (a *App) LockHiddenZone()             { a.hiddenModeActive = false }
+func (a *App) LockHiddenZone() {
+	if a.hiddenModeActive {
+		a.audit(auditHiddenLock, "hidden", true, "")
+	}
+	a.hiddenModeActive = false
+}
 func (a *App) IsHiddenZoneActive() bool    { return a.hiddenModeActive }
-func (a *App) HasHiddenZonePassword() bool { return a.getConfig("hidden_hash") != "" }
+func (a *App) HasHiddenZonePassword() bool { return a.getConfig(a.cfg("hidden_hash")) != "" }
 
 // --- SECURITY CHECK (ACCESS CONTROL) ---
-func (a *App) CheckAccess(bookName string) bool {
-	var book Book
-	if err := a.db.Where("LOWER(title) = ?", strings.ToLower(bookName)).First(&book).Error; err != nil {
-		return true 
-	}
+//
+// Default TOLAK. Isi buku (gambar, thumbnail, daftar chapter) hanya boleh
+// keluar jika vault terbuka, bukunya ada, dan tidak hidden / terkunci
+// (kecuali Hidden Zone aktif / buku sudah dibuka dengan password).
+
+var ErrAccessDenied = errors.New("akses ditolak")
+
+func (a *App) canAccess(book *Book) bool {
 	if book.IsHidden && !a.hiddenModeActive {
 		return false
 	}
-	if book.IsLocked && !a.unlockedBooks[book.Title] {
+	if _, ok := a.bookKey(book.ID); book.IsLocked && !ok {
 		return false
 	}
 	return true
 }
 
+// bookForAccess mencari buku berdasarkan ID (dari URL /img/ dan /thumbnail/).
+// Buku yang tidak ada dan buku yang tidak boleh diakses sama-sama
+// menghasilkan ErrAccessDenied, supaya keberadaan buku tidak bocor.
+func (a *App) bookForAccess(id uint) (*Book, error) {
+	if id == 0 || !a.IsVaultUnlocked() {
+		return nil, ErrAccessDenied
+	}
+	var book Book
+	if err := a.libraryDB().First(&book, id).Error; err != nil {
+		return nil, ErrAccessDenied
+	}
+	if !a.canAccess(&book) {
+		return nil, ErrAccessDenied
+	}
+	return &book, nil
+}
+
+// bookByTitleForAccess: versi bookForAccess untuk binding yang masih
+// memakai judul buku.
+func (a *App) bookByTitleForAccess(bookName string) (*Book, error) {
+	if !a.IsVaultUnlocked() {
+		return nil, ErrAccessDenied
+	}
+	var book Book
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
+		return nil, ErrAccessDenied
+	}
+	if !a.canAccess(&book) {
+		return nil, ErrAccessDenied
+	}
+	return &book, nil
+}
+
+func (a *App) CheckAccess(bookName string) bool {
+	_, err := a.bookByTitleForAccess(bookName)
+	return err == nil
+}
+
 // --- BOOK CRUD (HIGH PERFORMANCE IMPORT) ---
 
 // [UPDATE] Hapus parameter 'id int' karena tidak dipakai
-func (a *App) imageWorker(jobs <-chan ImportJob, wg *sync.WaitGroup) {
+func (a *App) imageWorker(ctx context.Context, jobs <-chan ImportJob, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for job := range jobs {
-		srcImg, err := imaging.Open(job.Path)
-		if err != nil {
-			job.ResultChan <- false
-			continue
-		}
-
-		if srcImg.Bounds().Dx() > maxWidth {
-			srcImg = imaging.Resize(srcImg, maxWidth, 0, imaging.Lanczos)
+		res := importResult{Rel: job.Page.Rel}
+		if res.Err = ctx.Err(); res.Err == nil {
+			res.Bytes, res.Err = a.importImage(job)
 		}
+		job.ResultChan <- res
+	}
+}
 
-		var buf bytes.Buffer
-		imaging.Encode(&buf, srcImg, imaging.JPEG, imaging.JPEGQuality(jpegQuality))
-		encData, _ := EncryptData(buf.Bytes())
+// importImage memproses satu halaman sesuai profil penyimpanan (lihat
+// storage.go), lalu mengenkripsinya.
+func (a *App) importImage(job ImportJob) (int64, error) {
+	data, mime, err := encodePage(job.Profile, job.Settings, job.Page)
+	if err != nil {
+		return 0, err
+	}
 
-		err = os.WriteFile(job.DestPath, encData, 0644)
-		job.ResultChan <- (err == nil)
+	// Sesi dikunci di tengah import (panic/auto-lock): kunci sudah dihapus
+	if !a.IsVaultUnlocked() {
+		return 0, ErrVaultLocked
+	}
+	size := int64(len(data))
+	if err := writeEncryptedFile(job.DestPath, job.Key, mime, size, bytes.NewReader(data)); err != nil {
+		return 0, fmt.Errorf("gagal menulis: %w", err)
 	}
+	return size, nil
 }
 
-func (a *App) CreateBook(bookName string, sourcePath string, syncMode bool) string {
+// CreateBook: import sinkron (menunggu sampai selesai). Frontend memakai
+// StartImport supaya progress terlihat dan import bisa dibatalkan.
+func (a *App) CreateBook(bookName string, sourcePath string, syncMode bool, opts ImportOptions) string {
 	if bookName == "" || sourcePath == "" {
 		return "Data kosong"
 	}
-	safeName := SanitizeName(bookName)
-	destPath := filepath.Join(a.vaultDir, safeName)
+	if !a.IsVaultUnlocked() {
+		return "Vault terkunci. Masukkan master password dulu."
+	}
+	// Jangan auto-lock (dan menghapus kunci) di tengah import
+	defer a.holdSession()()
 
-	var existingBook Book
-	if err := a.db.Where("path = ?", destPath).First(&existingBook).Error; err == nil && !syncMode {
-		return "Buku sudah ada di database."
+	report := a.importBook(context.Background(), newImportTracker(a, ""), bookName, sourcePath, syncMode, opts)
+	return report.Message()
+}
+
+// importBook mengimpor satu folder / file buku. Progress dilaporkan lewat
+// tracker; jika ctx dibatalkan, halaman yang belum diproses dilewati.
+func (a *App) importBook(ctx context.Context, tracker *importTracker, bookName, sourcePath string, syncMode bool, opts ImportOptions) (report ImportReport) {
+	report.Book = bookName
+	fail := func(msg string) ImportReport {
+		report.Error = msg
+		return report
 	}
 
-	if !syncMode {
-		os.MkdirAll(destPath, 0755)
+	source, err := scanImportSource(sourcePath)
+	if err != nil {
+		return fail("Gagal: " + err.Error())
+	}
+	defer source.Close()
+	report.Skipped = source.Skipped
+	meta := source.Meta
+	if meta != nil && meta.Title != "" && !syncMode &&
+		(opts.PreferSidecar || (source.Embedded && bookName == archiveBookName(sourcePath))) {
+		// Judul dari metadata menggantikan nama folder / file. Untuk .epub
+		// juga saat nama buku masih nama file (default). Field lain selalu
+		// dipakai, opsi ini hanya memilih judulnya
+		bookName = meta.Title
+		report.Book = bookName
 	}
 
-	// 1. SCANNING PHASE
-	type FileTask struct {
-		Source string
-		Dest   string
+	existingBook, found := a.findBookForImport(bookName)
+	if found && !syncMode {
+		return fail("Buku sudah ada di database.")
+	}
+
+	// Buku baru: folder bernama judul, atau ID acak jika opsi obfuscate aktif
+	obfuscated := a.GetObfuscateNames()
+	destPath := filepath.Join(a.vaultDir, SanitizeName(bookName))
+	profile, err := normalizeStorageProfile(opts.StorageProfile)
+	if err != nil {
+		return fail("Gagal: " + err.Error())
+	}
+	if found {
+		// Sync: ikut profil penyimpanan buku yang sudah ada
+		obfuscated = existingBook.Obfuscated
+		destPath = existingBook.Path
+		profile, _ = normalizeStorageProfile(existingBook.StorageProfile)
+	} else if obfuscated {
+		destPath = filepath.Join(a.vaultDir, opaqueName())
+	}
+	if !a.inVault(destPath) {
+		return fail("Gagal: " + errOutsideVault.Error())
 	}
-	var tasks []FileTask
-	var firstImage string
 
-	filepath.WalkDir(sourcePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
-			return nil
+	// Buku terkunci ditulis dengan kunci bukunya sendiri
+	var writeKey []byte
+	if syncMode && found {
+		key, err := a.bookWriteKey(&existingBook)
+		if err != nil {
+			return fail("Gagal: " + err.Error())
 		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".jpg" || ext == ".png" || ext == ".jpeg" || ext == ".webp" {
-			relPath, _ := filepath.Rel(sourcePath, path)
-			slashPath := filepath.ToSlash(relPath)
-			parts := strings.Split(slashPath, "/")
-			var safeParts []string
-			for i, p := range parts {
-				if i == len(parts)-1 {
-					safeParts = append(safeParts, SanitizeName(strings.TrimSuffix(p, filepath.Ext(p)))+".jpg")
-				} else {
-					safeParts = append(safeParts, SanitizeName(p))
-				}
-			}
-			finalDest := filepath.Join(destPath, filepath.Join(safeParts...))
-			
-			if firstImage == "" {
-				relCover, _ := filepath.Rel(destPath, finalDest)
-				firstImage = filepath.ToSlash(relCover)
+		writeKey = key
+	} else {
+		writeKey = a.sessionKey()
+	}
+	if writeKey == nil {
+		return fail("Gagal: " + ErrVaultLocked.Error())
+	}
+	defer wipeBytes(writeKey)
+
+	// 1. SCANNING PHASE: rencana import dicatat di jurnal (lihat importtx.go)
+	journal := &ImportJournal{
+		SourcePath: sourcePath,
+		BookName:   bookName,
+		DestPath:   destPath,
+		Obfuscated: obfuscated,
+
+		StorageProfile: profile,
+	}
+	if found {
+		journal.BookID = existingBook.ID
+	} else if meta != nil {
+		raw, _ := json.Marshal(meta)
+		journal.Meta = string(raw)
+	}
+	pages := make(map[string]importPage, len(source.Pages))
+
+	for _, page := range source.Pages {
+		parts := strings.Split(page.Rel, "/")
+		var safeParts []string
+		for i, p := range parts {
+			if i == len(parts)-1 {
+				safeParts = append(safeParts, SanitizeName(strings.TrimSuffix(p, path.Ext(p)))+pageExt(profile, page))
+			} else {
+				safeParts = append(safeParts, SanitizeName(p))
 			}
+		}
 
-			if syncMode {
-				if _, err := os.Stat(finalDest); !os.IsNotExist(err) {
-					return nil
+		task := ImportJournalTask{Rel: page.Rel, Dest: strings.Join(safeParts, "/")}
+		if obfuscated {
+			task.Chapter, task.Name = splitPagePath(task.Dest)
+			if found {
+				if _, err := a.pagePath(&existingBook, task.Chapter, task.Name); err == nil {
+					continue // sudah ada (sync)
 				}
 			}
+			task.Dest = opaqueName()
+		}
 
-			os.MkdirAll(filepath.Dir(finalDest), 0755)
-			tasks = append(tasks, FileTask{Source: path, Dest: finalDest})
+		if syncMode && !obfuscated {
+			if _, err := os.Stat(filepath.Join(destPath, filepath.FromSlash(task.Dest))); !os.IsNotExist(err) {
+				continue
+			}
 		}
-		return nil
-	})
 
-	if len(tasks) == 0 {
-		return "Tidak ada gambar baru ditemukan."
+		journal.Tasks = append(journal.Tasks, task)
+		pages[page.Rel] = page
 	}
 
+	if len(journal.Tasks) == 0 {
+		return fail("Tidak ada gambar baru ditemukan.")
+	}
+	if err := a.beginImport(journal); err != nil {
+		return fail("Gagal: " + err.Error())
+	}
+	result := a.runImportJournal(ctx, tracker, journal, pages, writeKey)
+	result.Skipped = source.Skipped
+	return result
+}
+
+// processImportJobs menjalankan worker pool untuk jobs dan mencatat
+// hasilnya ke report.
+func (a *App) processImportJobs(ctx context.Context, tracker *importTracker, bookName string, jobList []ImportJob, report *ImportReport) {
 	// 2. PROCESSING PHASE (Concurrency)
 	numWorkers := runtime.NumCPU() // Menggunakan 'runtime' asli Go
-	jobs := make(chan ImportJob, len(tasks))
-	results := make(chan bool, len(tasks))
+	jobs := make(chan ImportJob, len(jobList))
+	results := make(chan importResult, len(jobList))
 	var wg sync.WaitGroup
 
-for w := 0; w < numWorkers; w++ {
+	for w := 0; w < numWorkers; w++ {
 		wg.Add(1)
 		// [UPDATE] Jangan kirim 'w' lagi
-		go a.imageWorker(jobs, &wg)
+		go a.imageWorker(ctx, jobs, &wg)
 	}
 
-	for _, t := range tasks {
-		jobs <- ImportJob{
-			Path:       t.Source,
-			DestPath:   t.Dest,
-			ResultChan: results,
-		}
+	for _, job := range jobList {
+		job.ResultChan = results
+		jobs <- job
 	}
 	close(jobs)
-	wg.Wait()
-	close(results)
 
-	successCount := 0
-	for res := range results {
-		if res {
-			successCount++
+	tracker.begin(bookName, len(jobList))
+	for range jobList {
+		res := <-results
+		tracker.add(res)
+		switch {
+		case res.Err == nil:
+			report.Imported++
+			report.Bytes += res.Bytes
+		case errors.Is(res.Err, context.Canceled):
+			// Dibatalkan, bukan gagal
+		default:
+			report.Failed = append(report.Failed, ImportFailure{File: res.Rel, Error: res.Err.Error()})
 		}
 	}
+	wg.Wait()
+}
+
+// BatchImportBooks: versi sinkron dari StartBatchImport.
+func (a *App) BatchImportBooks(rootPath string, opts ImportOptions) []string {
+	if !a.IsVaultUnlocked() {
+		return []string{"Vault terkunci. Masukkan master password dulu."}
+	}
+	defer a.holdSession()()
+
+	items, err := batchImportItems(rootPath)
+	if err != nil {
+		return []string{"Gagal membaca folder: " + err.Error()}
+	}
+	reports := a.importBatch(context.Background(), newImportTracker(a, ""), items, opts)
 
-	// 3. DATABASE UPDATE
-	if !syncMode {
-		newBook := Book{
-			Title:     bookName,
-			Path:      destPath,
-			CoverPath: firstImage,
+	var logs []string
+	count := 0
+	for _, r := range reports {
+		if r.Error == "" {
+			count++
+		} else {
+			logs = append(logs, fmt.Sprintf("Skip [%s]: %s", r.Book, r.Error))
 		}
-		a.db.Create(&newBook)
 	}
+	summary := fmt.Sprintf("Selesai! %d buku berhasil diimpor.", count)
+	return append([]string{summary}, logs...)
+}
 
-	return fmt.Sprintf("Sukses! %d gambar diimpor (Parallel Mode).", successCount)
+// batchImportItem: satu subfolder / file buku di folder batch import.
+type batchImportItem struct {
+	Name string // judul default
+	Path string
 }
 
-func (a *App) BatchImportBooks(rootPath string) []string {
-	var logs []string
+// batchImportItems mendaftar setiap subfolder dan file buku
+// (.cbz/.zip/.pdf/.epub) di rootPath; masing-masing jadi satu buku.
+func batchImportItems(rootPath string) ([]batchImportItem, error) {
 	entries, err := os.ReadDir(rootPath)
 	if err != nil {
-		return []string{"Gagal membaca folder: " + err.Error()}
+		return nil, err
 	}
-	count := 0
+	var items []batchImportItem
 	for _, entry := range entries {
-		if entry.IsDir() {
-			bookName := entry.Name()
-			fullPath := filepath.Join(rootPath, bookName)
-			res := a.CreateBook(bookName, fullPath, false)
-			if strings.Contains(res, "Sukses") {
-				count++
-			} else {
-				logs = append(logs, fmt.Sprintf("Skip [%s]: %s", bookName, res))
+		bookName := entry.Name()
+		if !entry.IsDir() {
+			if !isImportFile(bookName) {
+				continue
 			}
+			bookName = archiveBookName(bookName)
 		}
+		items = append(items, batchImportItem{Name: bookName, Path: filepath.Join(rootPath, entry.Name())})
 	}
-	summary := fmt.Sprintf("Selesai! %d buku berhasil diimpor.", count)
-	return append([]string{summary}, logs...)
+	return items, nil
 }
 
-// [BARU] Helper untuk main.go mengambil path cover
-func (a *App) GetBookCoverPath(bookName string) (string, error) {
-	var book Book
-	// Cari path relatif cover
-	if err := a.db.Where("title = ?", bookName).First(&book).Error; err != nil {
-		return "", err
-	}
-	// return: "NamaBuku/Chapter1/01.jpg"
-	// Ingat path di DB adalah Full Path, kita butuh relatif terhadap Vault untuk main.go
-	// Tapi tunggu, Book struct punya 'CoverPath' yang relatif terhadap folder buku.
-	// Dan 'Path' adalah full path folder buku.
-	// Kita perlu mengembalikan path relatif terhadap VAULT DIR.
-	
-	// Konstruksi ulang: Book Folder Name + Cover Path
-	bookDirName := filepath.Base(book.Path)
-	relCover := filepath.Join(bookDirName, book.CoverPath)
-	return relCover, nil
+func (a *App) importBatch(ctx context.Context, tracker *importTracker, items []batchImportItem, opts ImportOptions) []ImportReport {
+	var reports []ImportReport
+	for i, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		tracker.nextBook(i+1, len(items))
+		reports = append(reports, a.importBook(ctx, tracker, item.Name, item.Path, false, opts))
+	}
+	return reports
+}
+
+// applyImportMetadata mengisi field buku baru dari metadata sumber import.
+func (a *App) applyImportMetadata(book *Book, meta *bookMetadata) {
+	book.Author = meta.Author
+	book.Artist = meta.Artist
+	book.Volume = meta.Volume
+	book.Number = meta.Number
+	book.Language = meta.Language
+	book.ReadingDirection = meta.Direction
+	book.Description = meta.Description
+	if meta.Series != "" {
+		if series, err := a.ensureSeries(meta.Series); err == nil {
+			book.SeriesID = &series.ID
+		}
+	}
+	for _, tName := range meta.Tags {
+		var t Tag
+		if err := a.libraryDB().FirstOrCreate(&t, Tag{Name: tName}).Error; err == nil {
+			book.Tags = append(book.Tags, t)
+		}
+	}
+}
+
+// coverFile: path file cover di disk (selalu di dalam folder buku).
+func (a *App) coverFile(book *Book) (string, error) {
+	if book.CoverPath == "" {
+		return "", os.ErrNotExist
+	}
+	if book.Obfuscated {
+		// CoverPath buku obfuscated adalah nama file acak di root buku
+		return filepath.Join(book.Path, filepath.Base(book.CoverPath)), nil
+	}
+	chapter, name := splitPagePath(book.CoverPath)
+	return a.pagePath(book, chapter, name)
 }
 
 // [UPDATE] GetBooks dengan Pagination yang Benar
@@ -364,7 +722,7 @@ func (a *App) GetBooks(filter SearchQuery) []BookFrontend {
 	var result []BookFrontend
 
 	// Preload Tags dan Series
-	db := a.db.Model(&Book{}).Preload("Tags").Preload("Series")
+	db := a.libraryDB().Model(&Book{}).Preload("Tags").Preload("Series")
 
 	// --- FILTERING (Sama seperti sebelumnya) ---
 	if !a.hiddenModeActive {
@@ -372,7 +730,7 @@ func (a *App) GetBooks(filter SearchQuery) []BookFrontend {
 	}
 	if filter.Query != "" {
 		likeQuery := "%" + strings.ToLower(filter.Query) + "%"
-		db = db.Where("LOWER(title) LIKE ?", likeQuery)
+		db = db.Where("(LOWER(title) LIKE ? OR LOWER(author) LIKE ?)", likeQuery, likeQuery)
 	}
 	if filter.OnlyFav {
 		db = db.Where("is_favorite = ?", true)
@@ -424,6 +782,7 @@ func (a *App) GetBooks(filter SearchQuery) []BookFrontend {
         }
 
 		result = append(result, BookFrontend{
+			ID:           b.ID,
 			Name:         b.Title,
 			Cover:        "", // Frontend pakai Thumbnail URL
 			Tags:         tagNames,
@@ -435,6 +794,14 @@ func (a *App) GetBooks(filter SearchQuery) []BookFrontend {
 			IsFavorite:   b.IsFavorite,
 			LastReadTime: b.LastReadTime.Unix(),
             SeriesName:   seriesName,
+
+			Author:           b.Author,
+			Artist:           b.Artist,
+			Volume:           b.Volume,
+			Number:           b.Number,
+			Language:         b.Language,
+			ReadingDirection: b.ReadingDirection,
+			StorageProfile:   b.StorageProfile,
 		})
 	}
 	return result
@@ -442,13 +809,19 @@ func (a *App) GetBooks(filter SearchQuery) []BookFrontend {
 
 func (a *App) UpdateBookMetadata(bookName, newName, description string, tags []string, isHidden, maskCover bool) error {
 	var book Book
-	if err := a.db.Where("title = ?", bookName).First(&book).Error; err != nil {
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
 		return fmt.Errorf("buku tidak ditemukan")
 	}
 
-	if newName != bookName && newName != "" {
+	if newName != bookName && newName != "" && book.Obfuscated {
+		// Folder bernama acak, cukup ganti judul di database
+		book.Title = newName
+	} else if newName != bookName && newName != "" {
 		newSafe := SanitizeName(newName)
 		newPath := filepath.Join(a.vaultDir, newSafe)
+		if !a.inVault(book.Path) || !a.inVault(newPath) {
+			return errOutsideVault
+		}
 		if err := os.Rename(book.Path, newPath); err != nil {
 			return err
 		}
@@ -460,22 +833,22 @@ func (a *App) UpdateBookMetadata(bookName, newName, description string, tags []s
 	book.IsHidden = isHidden
 	book.MaskCover = maskCover
 
-	a.db.Model(&book).Association("Tags").Clear()
+	a.libraryDB().Model(&book).Association("Tags").Clear()
 	var newTags []Tag
 	for _, tName := range tags {
 		cleanName := strings.TrimSpace(tName)
 		if cleanName != "" {
 			var t Tag
-			a.db.FirstOrCreate(&t, Tag{Name: cleanName})
+			a.libraryDB().FirstOrCreate(&t, Tag{Name: cleanName})
 			newTags = append(newTags, t)
 		}
 	}
 	book.Tags = newTags
-	return a.db.Save(&book).Error
+	return a.libraryDB().Save(&book).Error
 }
 
 func (a *App) UpdateBookProgress(bookName string, pageIndex int) error {
-	return a.db.Model(&Book{}).Where("title = ?", bookName).Updates(map[string]interface{}{
+	return a.libraryDB().Model(&Book{}).Where("title = ?", bookName).Updates(map[string]interface{}{
 		"last_page":      pageIndex,
 		"last_read_time": time.Now(),
 	}).Error
@@ -483,58 +856,49 @@ func (a *App) UpdateBookProgress(bookName string, pageIndex int) error {
 
 func (a *App) ToggleBookFavorite(bookName string) (bool, error) {
 	var book Book
-	if err := a.db.Where("title = ?", bookName).First(&book).Error; err != nil {
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
 		return false, err
 	}
 	newStatus := !book.IsFavorite
-	err := a.db.Model(&book).Update("is_favorite", newStatus).Error
+	err := a.libraryDB().Model(&book).Update("is_favorite", newStatus).Error
 	return newStatus, err
 }
 
 func (a *App) DeleteBook(bookName string) error {
 	var book Book
-	if err := a.db.Where("title = ?", bookName).First(&book).Error; err != nil {
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
 		return err
 	}
-	os.RemoveAll(book.Path)
-	return a.db.Unscoped().Delete(&book).Error
+	// Path dari data lama bisa menunjuk ke folder vault itu sendiri (atau
+	// di luarnya): file tidak dihapus, cukup data bukunya
+	err := errOutsideVault
+	if a.inVault(book.Path) {
+		err = secureRemoveAll(book.Path)
+	}
+	if err != nil {
+		log.Printf("hapus buku [%d]: %v", book.ID, err)
+	}
+	a.audit(auditBookDelete, bookTarget(book.ID), err == nil, auditDetail(err))
+	a.dropThumbnail(&book)
+	a.libraryDB().Where("book_id = ?", book.ID).Delete(&VaultEntry{})
+	return a.libraryDB().Unscoped().Delete(&book).Error
 }
 
 // --- CHAPTERS & READERS ---
 func (a *App) GetChapters(bookName string) []string {
-	var book Book
-	if err := a.db.Where("title = ?", bookName).First(&book).Error; err != nil {
+	book, err := a.bookByTitleForAccess(bookName)
+	if err != nil {
 		return []string{}
 	}
-	var chapters []string
-	entries, _ := os.ReadDir(book.Path)
-	for _, e := range entries {
-		if e.IsDir() {
-			chapters = append(chapters, e.Name())
-		}
-	}
-	natsort(chapters)
-	return chapters
+	return a.bookChapters(book)
 }
 
 func (a *App) GetImagesInChapter(bookName, chapterName string) []string {
-	var book Book
-	if err := a.db.Where("title = ?", bookName).First(&book).Error; err != nil {
+	book, err := a.bookByTitleForAccess(bookName)
+	if err != nil {
 		return []string{}
 	}
-	targetPath := book.Path
-	if chapterName != "" {
-		targetPath = filepath.Join(targetPath, chapterName)
-	}
-	var files []string
-	entries, _ := os.ReadDir(targetPath)
-	for _, e := range entries {
-		if !e.IsDir() && strings.HasSuffix(strings.ToLower(e.Name()), ".jpg") {
-			files = append(files, e.Name())
-		}
-	}
-	natsort(files)
-	return files
+	return a.bookPages(book, chapterName)
 }
 
 // --- SERIES MANAGEMENT ---
@@ -546,6 +910,7 @@ type SeriesFrontend struct {
 	Description string `json:"description"`
 	Count       int64  `json:"count"`      // Jumlah buku
 	Cover       string `json:"cover_book"` // Nama buku untuk diambil thumbnail-nya
+	CoverID     uint   `json:"cover_id"`   // ID buku cover (/thumbnail/<id>)
 }
 
 // 1. Ambil Daftar Series
@@ -555,18 +920,20 @@ func (a *App) GetAllSeries() []SeriesFrontend {
 
 	// Ambil semua series dengan preload Books (hanya butuh 1 buku untuk cover)
 	// Kita gunakan subquery atau logic manual biar efisien
-	a.db.Model(&Series{}).Preload("Books", func(db *gorm.DB) *gorm.DB {
+	a.libraryDB().Model(&Series{}).Preload("Books", func(db *gorm.DB) *gorm.DB {
 		return db.Select("id, series_id, title").Order("title asc").Limit(1)
 	}).Find(&series)
 
 	for _, s := range series {
 		// Hitung jumlah buku
 		var count int64
-		a.db.Model(&Book{}).Where("series_id = ?", s.ID).Count(&count)
+		a.libraryDB().Model(&Book{}).Where("series_id = ?", s.ID).Count(&count)
 		
 		coverBook := ""
+		var coverID uint
 		if len(s.Books) > 0 {
 			coverBook = s.Books[0].Title
+			coverID = s.Books[0].ID
 		}
 
 		result = append(result, SeriesFrontend{
@@ -574,23 +941,32 @@ func (a *App) GetAllSeries() []SeriesFrontend {
 			Title:       s.Title,
 			Description: s.Description,
 			Count:       count,
-			Cover:       coverBook, // Frontend akan request /thumbnail/CoverBook
+			Cover:       coverBook,
+			CoverID:     coverID, // Frontend akan request /thumbnail/<id>
 		})
 	}
 	return result
 }
 
+// ensureSeries mengambil series berjudul name, atau membuatnya jika belum ada
+// (dipakai saat import buku yang membawa metadata series).
+func (a *App) ensureSeries(name string) (*Series, error) {
+	var series Series
+	err := a.libraryDB().Where(Series{Title: name}).FirstOrCreate(&series).Error
+	return &series, err
+}
+
 // 2. Buat Series Baru
 func (a *App) CreateSeries(name, desc string) string {
 	if name == "" { return "Nama tidak boleh kosong" }
 	
 	// Cek duplikat
 	var count int64
-	a.db.Model(&Series{}).Where("title = ?", name).Count(&count)
+	a.libraryDB().Model(&Series{}).Where("title = ?", name).Count(&count)
 	if count > 0 { return "Series sudah ada" }
 
 	newSeries := Series{Title: name, Description: desc}
-	if err := a.db.Create(&newSeries).Error; err != nil {
+	if err := a.libraryDB().Create(&newSeries).Error; err != nil {
 		return "Error: " + err.Error()
 	}
 	return "OK"
@@ -599,33 +975,48 @@ func (a *App) CreateSeries(name, desc string) string {
 // 3. Tambahkan Buku ke Series
 func (a *App) AddBookToSeries(bookName, seriesName string) error {
 	var series Series
-	if err := a.db.Where("title = ?", seriesName).First(&series).Error; err != nil {
+	if err := a.libraryDB().Where("title = ?", seriesName).First(&series).Error; err != nil {
 		return fmt.Errorf("series tidak ditemukan")
 	}
 
 	var book Book
-	if err := a.db.Where("title = ?", bookName).First(&book).Error; err != nil {
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
 		return fmt.Errorf("buku tidak ditemukan")
 	}
 
 	// Update relasi
 	book.SeriesID = &series.ID
-	return a.db.Save(&book).Error
+	return a.libraryDB().Save(&book).Error
 }
 
 // 4. Keluarkan Buku dari Series
 func (a *App) RemoveBookFromSeries(bookName string) error {
-	return a.db.Model(&Book{}).Where("title = ?", bookName).Update("series_id", nil).Error
+	return a.libraryDB().Model(&Book{}).Where("title = ?", bookName).Update("series_id", nil).Error
 }
 
 // 5. Hapus Series (Buku tidak terhapus, cuma ungroup)
 func (a *App) DeleteSeries(name string) error {
 	// Karena constraint OnDelete: SET NULL di models, buku otomatis lepas dari series
-	return a.db.Where("title = ?", name).Delete(&Series{}).Error
+	return a.libraryDB().Where("title = ?", name).Delete(&Series{}).Error
 }
 
 func (a *App) SetBookCover(bookName, imageName string) error {
-	return a.db.Model(&Book{}).Where("title = ?", bookName).Update("cover_path", imageName).Error
+	var book Book
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
+		return fmt.Errorf("buku tidak ditemukan")
+	}
+	if book.Obfuscated {
+		// imageName dari frontend adalah "Chapter/001.jpg", simpan nama di disk
+		chapter, name := splitPagePath(imageName)
+		full, err := a.pagePath(&book, chapter, name)
+		if err != nil {
+			return fmt.Errorf("halaman tidak ditemukan")
+		}
+		imageName = filepath.Base(full)
+	}
+	// Thumbnail cover lama tidak berlaku lagi
+	a.dropThumbnail(&book)
+	return a.libraryDB().Model(&book).Update("cover_path", imageName).Error
 }
 
 func (a *App) SelectFolder() string {
@@ -634,42 +1025,135 @@ func (a *App) SelectFolder() string {
 	return res
 }
 
-func (a *App) LockBook(bookName, p string) error {
+// [BARU] SelectArchive: pilih file buku (.cbz/.zip/.pdf/.epub) untuk diimpor.
+func (a *App) SelectArchive() string {
+	res, _ := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title:   "Pilih File Buku",
+		Filters: []wailsRuntime.FileFilter{{DisplayName: "Komik / PDF / EPUB (*.cbz, *.zip, *.pdf, *.epub)", Pattern: "*.cbz;*.zip;*.pdf;*.epub"}},
+	})
+	return res
+}
+
+// LockBook mengunci buku dengan kunci enkripsi sendiri (dibungkus password buku).
+func (a *App) LockBook(bookName, p string) (err error) {
 	if p == "" {
 		return fmt.Errorf("password tidak boleh kosong")
 	}
-	return a.db.Model(&Book{}).Where("title = ?", bookName).Updates(map[string]interface{}{
-		"is_locked":     true,
-		"password_hash": HashString(p),
-	}).Error
+	if !a.IsVaultUnlocked() {
+		return ErrVaultLocked
+	}
+	defer a.holdSession()()
+	var book Book
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
+		return fmt.Errorf("buku tidak ditemukan")
+	}
+	if book.IsLocked && book.KeySlot != "" {
+		return fmt.Errorf("buku sudah terkunci")
+	}
+	defer func() { a.audit(auditBookLock, bookTarget(book.ID), err == nil, auditDetail(err)) }()
+
+	key, err := a.createBookKey(&book, p)
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(key)
+	// Thumbnail lama dienkripsi dengan kunci vault, buat ulang nanti
+	a.dropThumbnail(&book)
+	return a.sealBook(&book, key)
 }
 
-func (a *App) UnlockBook(bookName string) error {
-	return a.db.Model(&Book{}).Where("title = ?", bookName).Updates(map[string]interface{}{
-		"is_locked":     false,
-		"password_hash": "",
+// UnlockBook menghapus proteksi: halaman dienkripsi ulang ke kunci vault.
+// Buku harus sudah dibuka dengan VerifyBookPassword di sesi ini.
+func (a *App) UnlockBook(bookName string) (err error) {
+	defer a.holdSession()()
+	var book Book
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
+		return fmt.Errorf("buku tidak ditemukan")
+	}
+	defer func() { a.audit(auditBookUnlock, bookTarget(book.ID), err == nil, auditDetail(err)) }()
+	if book.IsLocked && book.KeySlot != "" {
+		key, ok := a.bookKey(book.ID)
+		if !ok || key == nil {
+			return fmt.Errorf("buka buku dengan password dulu")
+		}
+		vaultKey := a.sessionKey()
+		if vaultKey == nil {
+			return ErrVaultLocked
+		}
+		defer wipeBytes(vaultKey)
+		a.libraryDB().Model(&book).Update("crypto_pending", true)
+		if err := a.reencryptBook(&book, vaultKey, key); err != nil {
+			return err
+		}
+	}
+	a.forgetBookKey(book.ID)
+	return a.libraryDB().Model(&book).Updates(map[string]interface{}{
+		"is_locked":      false,
+		"password_hash":  "",
+		"key_slot":       "",
+		"crypto_pending": false,
 	}).Error
 }
 
 func (a *App) VerifyBookPassword(bookName, p string) bool {
+	defer a.holdSession()()
 	var book Book
-	if err := a.db.Where("title = ?", bookName).First(&book).Error; err != nil {
+	if err := a.libraryDB().Where("title = ?", bookName).First(&book).Error; err != nil {
 		return false
 	}
 	if book.IsLocked && book.PasswordHash == "" {
-		a.unlockedBooks[bookName] = true
+		a.setBookKey(book.ID, nil)
 		return true
 	}
-	if HashString(p) == book.PasswordHash {
-		a.unlockedBooks[bookName] = true
-		return true
+	scope := bookTarget(book.ID)
+	if a.retryAfter(scope) > 0 {
+		a.audit(auditBookPassword, scope, false, "throttled")
+		return false
 	}
-	return false
+	ok, upgrade := CheckPassword(p, book.PasswordHash)
+	a.recordAttempt(scope, ok)
+	a.audit(auditBookPassword, scope, ok, "")
+	if !ok {
+		return false
+	}
+	// Hash lama di-upgrade di setiap kecocokan, apa pun bentuk kunci bukunya
+	if upgrade {
+		book.PasswordHash = HashPassword(p)
+		a.libraryDB().Model(&book).Update("password_hash", book.PasswordHash)
+	}
+
+	key, err := a.openBookKey(&book, p)
+	if err != nil {
+		return false
+	}
+	a.setBookKey(book.ID, key)
+	if book.CryptoPending {
+		if err := a.sealBook(&book, key); err != nil {
+			log.Printf("enkripsi ulang buku [%s] belum selesai: %v", book.Title, err)
+		}
+	}
+	return true
 }
 
+var unsafeNameChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+
+// SanitizeName membuat nama yang aman dipakai sebagai SATU komponen path.
+// Judul bisa datang dari metadata (OPF, ComicInfo.xml, info.json), jadi
+// pemisah folder ikut diganti dan "", "." atau ".." tidak pernah lolos.
 func SanitizeName(name string) string {
-	re := regexp.MustCompile(`[<>:"|?*]`)
-	return strings.TrimSpace(re.ReplaceAllString(name, "_"))
+	name = strings.TrimSpace(unsafeNameChars.ReplaceAllString(name, "_"))
+	if strings.Trim(name, ".") == "" {
+		return "_" + name
+	}
+	return name
+}
+
+var errOutsideVault = errors.New("lokasi folder buku tidak valid")
+
+// inVault: p adalah folder buku langsung di bawah folder vault profil
+// aktif. Dicek sebelum folder buku ditulis, dipindah atau dihapus.
+func (a *App) inVault(p string) bool {
+	return filepath.Dir(filepath.Clean(p)) == filepath.Clean(a.vaultDir)
 }
 func natsort(s []string) {
 	sort.Slice(s, func(i, j int) bool { return naturalCompare(s[i], s[j]) })
@@ -722,13 +1206,13 @@ func (a *App) GetDashboardStats() DashboardStats {
 	var stats DashboardStats
 
 	// Hitung Total
-	a.db.Model(&Book{}).Count(&stats.TotalBooks)
-	a.db.Model(&Series{}).Count(&stats.TotalSeries)
-	a.db.Model(&Tag{}).Count(&stats.TotalTags)
+	a.libraryDB().Model(&Book{}).Count(&stats.TotalBooks)
+	a.libraryDB().Model(&Series{}).Count(&stats.TotalSeries)
+	a.libraryDB().Model(&Tag{}).Count(&stats.TotalTags)
 
 	// Ambil Top 10 Tags
 	// Query SQL Agak kompleks: Join tags & book_tags, Group by tag name, Order by count
-	a.db.Table("tags").
+	a.libraryDB().Table("tags").
 		Select("tags.name, count(book_tags.book_id) as count").
 		Joins("left join book_tags on book_tags.tag_id = tags.id").
 		Group("tags.id").
@@ -749,7 +1233,7 @@ func (a *App) GetDashboardStats() DashboardStats {
 // 2. Ambil SEMUA Tag (Untuk Manager)
 func (a *App) GetAllTagsAdmin() []TagWithCount {
 	var tags []TagWithCount
-	a.db.Table("tags").
+	a.libraryDB().Table("tags").
 		Select("tags.name, count(book_tags.book_id) as count").
 		Joins("left join book_tags on book_tags.tag_id = tags.id").
 		Group("tags.id").
@@ -764,31 +1248,31 @@ func (a *App) RenameTag(oldName, newName string) string {
 	
 	// Cek apakah tag target sudah ada
 	var targetTag Tag
-	if err := a.db.Where("name = ?", newName).First(&targetTag).Error; err == nil {
+	if err := a.libraryDB().Where("name = ?", newName).First(&targetTag).Error; err == nil {
 		// KASUS MERGE: Tag baru sudah ada (misal rename 'Actionn' ke 'Action').
 		// Kita harus memindahkan semua buku dari tag lama ke tag baru, lalu hapus tag lama.
 		
 		var oldTag Tag
-		if err := a.db.Where("name = ?", oldName).First(&oldTag).Error; err != nil {
+		if err := a.libraryDB().Where("name = ?", oldName).First(&oldTag).Error; err != nil {
 			return "Tag lama tidak ditemukan"
 		}
 
 		// Ambil semua buku yang punya tag lama
 		var books []Book
-		a.db.Model(&oldTag).Association("Books").Find(&books)
+		a.libraryDB().Model(&oldTag).Association("Books").Find(&books)
 
 		// Tambahkan tag baru ke buku-buku tersebut
 		for _, b := range books {
-			a.db.Model(&b).Association("Tags").Append(&targetTag)
+			a.libraryDB().Model(&b).Association("Tags").Append(&targetTag)
 		}
 
 		// Hapus tag lama
-		a.db.Delete(&oldTag)
+		a.libraryDB().Delete(&oldTag)
 		return "Tag berhasil di-merge!"
 	}
 
 	// KASUS RENAME BIASA: Tag target belum ada. Cukup update nama.
-	if err := a.db.Model(&Tag{}).Where("name = ?", oldName).Update("name", newName).Error; err != nil {
+	if err := a.libraryDB().Model(&Tag{}).Where("name = ?", oldName).Update("name", newName).Error; err != nil {
 		return "Error: " + err.Error()
 	}
 	return "Tag berhasil di-rename!"
@@ -797,15 +1281,15 @@ func (a *App) RenameTag(oldName, newName string) string {
 // 4. Hapus Tag (Dari Database & Semua Buku)
 func (a *App) DeleteTagMaster(tagName string) string {
 	var tag Tag
-	if err := a.db.Where("name = ?", tagName).First(&tag).Error; err != nil {
+	if err := a.libraryDB().Where("name = ?", tagName).First(&tag).Error; err != nil {
 		return "Tag tidak ditemukan"
 	}
 
 	// Hapus relasi di tabel pivot book_tags
-	a.db.Model(&tag).Association("Books").Clear()
+	a.libraryDB().Model(&tag).Association("Books").Clear()
 	
 	// Hapus tag dari tabel tags
-	a.db.Delete(&tag)
+	a.libraryDB().Delete(&tag)
 	
 	return "Tag berhasil dihapus permanen"
 }
\ No newline at end of file
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- AUDIT LOG KEAMANAN ---
+//
+// Tabel audit_entries ada di library.db (bukan library terenkripsi) karena
+// percobaan login gagal justru terjadi saat vault terkunci. Isinya tidak
+// memuat judul buku, hanya target seperti "book:12".
+//
+// Append-only: update/delete ditolak lewat hook GORM. Tamper-evident: tiap
+// entry menyimpan hash entry sebelumnya (hash chain), jadi entry yang
+// diubah/dihapus langsung terlihat saat rantai diverifikasi.
+//
+// Rantai SHA-256 saja bisa ditulis ulang / dipotong oleh siapa pun yang
+// bisa mengedit library.db, jadi library terenkripsi tiap profil menyimpan
+// AuditState: ID + hash entry terakhir yang dicatat saat profil itu terbuka
+// (checkpoint). Rantai yang ujungnya hilang atau hash-nya berbeda dari
+// checkpoint dianggap rusak; dicek saat unlock dan di GetAuditLog.
+//
+// Entry yang dicatat saat vault terbuka diberi Tag = HMAC(AuditState.Key,
+// ID|Time): hanya profil pemilik kunci yang bisa mengenali entry miliknya,
+// sedangkan di library.db tag tiap entry terlihat acak (tidak membocorkan
+// adanya profil lain). Entry tanpa tag (percobaan login saat terkunci)
+// tampil di semua profil.
+
+var errAuditAppendOnly = errors.New("audit log tidak boleh diubah")
+
+// Event yang dicatat
+const (
+	auditUnlock         = "unlock"          // master password (login)
+	auditPasswordChange = "password_change" // ganti master password
+	auditKeyRotation    = "key_rotation"
+	auditRecoveryKey    = "recovery_key" // buat recovery key baru
+	auditRecover        = "recover"      // reset password dengan recovery key
+	auditSessionLock    = "session_lock" // manual / idle / blur / panic
+	auditPanic          = "panic"
+	auditHiddenZone     = "hidden_zone" // buka Hidden Zone
+	auditHiddenLock     = "hidden_lock"
+	auditBookLock       = "book_lock"     // pasang proteksi buku
+	auditBookUnlock     = "book_unlock"   // hapus proteksi buku
+	auditBookPassword   = "book_password" // VerifyBookPassword
+	auditBookDelete     = "book_delete"
+)
+
+func (AuditEntry) BeforeUpdate(*gorm.DB) error { return errAuditAppendOnly }
+func (AuditEntry) BeforeDelete(*gorm.DB) error { return errAuditAppendOnly }
+
+func (e *AuditEntry) computeHash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%d|%d|%s|%s|%t|%s", e.PrevHash, e.ID, e.Time, e.Event, e.Target, e.Success, e.Detail)
+	if e.Tag != "" {
+		// Entry lama (sebelum ada tag) tetap terverifikasi dengan format lama
+		fmt.Fprintf(h, "|%s", e.Tag)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func auditTag(key []byte, e *AuditEntry) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d|%d", e.ID, e.Time)
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// ownedBy: entry dicatat oleh profil pemilik state (atau tanpa tag).
+func (e *AuditEntry) ownedBy(st *AuditState) bool {
+	if e.Tag == "" {
+		return true
+	}
+	return st != nil && hmac.Equal([]byte(e.Tag), []byte(auditTag(st.Key, e)))
+}
+
+// auditState mengembalikan library yang terbuka beserta AuditState profilnya,
+// dibuat jika belum ada (checkpoint awal = ujung rantai saat ini). nil jika
+// vault terkunci. auditMu harus dipegang pemanggil.
+func (a *App) auditState() (*gorm.DB, *AuditState) {
+	a.libMu.RLock()
+	db, open := a.db, a.libraryOpen
+	a.libMu.RUnlock()
+	if !open {
+		return nil, nil
+	}
+	var st AuditState
+	if err := db.Limit(1).Find(&st).Error; err != nil {
+		log.Printf("audit: %v", err)
+		return nil, nil
+	}
+	if st.ID != 0 {
+		return db, &st
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil
+	}
+	var last AuditEntry
+	a.configDB.Order("id desc").Limit(1).Find(&last)
+	st = AuditState{ID: 1, Key: key, HeadID: last.ID, HeadHash: last.Hash}
+	if err := db.Create(&st).Error; err != nil {
+		log.Printf("audit: %v", err)
+		return nil, nil
+	}
+	return db, &st
+}
+
+func bookTarget(id uint) string { return "book:" + strconv.FormatUint(uint64(id), 10) }
+
+// audit menambah satu entry. Gagal menulis log tidak menggagalkan aksi.
+func (a *App) audit(event, target string, success bool, detail string) {
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+	db, st := a.auditState()
+	var head AuditEntry
+	err := a.configDB.Transaction(func(tx *gorm.DB) error {
+		var last AuditEntry
+		if err := tx.Order("id desc").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		e := AuditEntry{
+			ID:       last.ID + 1,
+			Time:     time.Now().UnixNano(),
+			Event:    event,
+			Target:   target,
+			Success:  success,
+			Detail:   detail,
+			PrevHash: last.Hash,
+		}
+		if st != nil {
+			e.Tag = auditTag(st.Key, &e)
+		}
+		e.Hash = e.computeHash()
+		head = e
+		return tx.Create(&e).Error
+	})
+	if err != nil {
+		log.Printf("audit: gagal mencatat %s: %v", event, err)
+		return
+	}
+	if st != nil {
+		db.Model(st).Updates(map[string]interface{}{"head_id": head.ID, "head_hash": head.Hash})
+	}
+}
+
+type AuditFilter struct {
+	Event        string `json:"event"`         // "" = semua
+	Target       string `json:"target"`        // mis. "master", "book:12"
+	OnlyFailures bool   `json:"only_failures"` // hanya percobaan gagal
+	Since        int64  `json:"since"`         // unix detik, 0 = semua
+	Limit        int    `json:"limit"`
+	Page         int    `json:"page"`
+}
+
+type AuditEntryFrontend struct {
+	ID      uint   `json:"id"`
+	Time    int64  `json:"time"` // unix detik
+	Event   string `json:"event"`
+	Target  string `json:"target"`
+	Success bool   `json:"success"`
+	Detail  string `json:"detail"`
+}
+
+type AuditLogResult struct {
+	Entries  []AuditEntryFrontend `json:"entries"`
+	Total    int64                `json:"total"`
+	Intact   bool                 `json:"intact"`    // rantai hash utuh
+	BrokenAt uint                 `json:"broken_at"` // ID entry pertama yang tidak cocok
+}
+
+// GetAuditLog mengembalikan log profil yang sedang terbuka (terbaru dulu)
+// dan status integritasnya. Hanya bisa dibuka saat vault terbuka.
+func (a *App) GetAuditLog(filter AuditFilter) (AuditLogResult, error) {
+	var res AuditLogResult
+	if !a.IsVaultUnlocked() {
+		return res, ErrVaultLocked
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+
+	q := a.configDB.Model(&AuditEntry{})
+	if filter.Event != "" {
+		q = q.Where("event = ?", filter.Event)
+	}
+	if filter.Target != "" {
+		q = q.Where("target = ?", filter.Target)
+	}
+	if filter.OnlyFailures {
+		q = q.Where("success = ?", false)
+	}
+	if filter.Since > 0 {
+		q = q.Where("time >= ?", time.Unix(filter.Since, 0).UnixNano())
+	}
+
+	// Tag hanya bisa dicek di sini (HMAC), jadi paging dilakukan setelah filter
+	a.auditMu.Lock()
+	_, st := a.auditState()
+	a.auditMu.Unlock()
+	var all, entries []AuditEntry
+	q.Order("id desc").Find(&all)
+	for i := range all {
+		if all[i].ownedBy(st) {
+			entries = append(entries, all[i])
+		}
+	}
+	res.Total = int64(len(entries))
+	start := min((filter.Page-1)*filter.Limit, len(entries))
+	entries = entries[start:min(start+filter.Limit, len(entries))]
+	for _, e := range entries {
+		res.Entries = append(res.Entries, AuditEntryFrontend{
+			ID:      e.ID,
+			Time:    time.Unix(0, e.Time).Unix(),
+			Event:   e.Event,
+			Target:  e.Target,
+			Success: e.Success,
+			Detail:  e.Detail,
+		})
+	}
+
+	res.BrokenAt = a.verifyAuditChain()
+	res.Intact = res.BrokenAt == 0
+	return res, nil
+}
+
+// verifyAuditChain memeriksa seluruh rantai. Return 0 jika utuh, atau ID
+// entry pertama yang rusak (hash salah, PrevHash tidak nyambung, ID loncat,
+// tidak cocok dengan checkpoint profil), atau ID pertama yang hilang jika
+// rantai terpotong sebelum checkpoint.
+func (a *App) verifyAuditChain() uint {
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+	_, st := a.auditState()
+	var broken uint
+	prev := AuditEntry{}
+	var batch []AuditEntry
+	a.configDB.Order("id asc").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, e := range batch {
+			if e.ID != prev.ID+1 || e.PrevHash != prev.Hash || e.Hash != e.computeHash() ||
+				(st != nil && e.ID == st.HeadID && e.Hash != st.HeadHash) {
+				broken = e.ID
+				return errors.New("stop")
+			}
+			prev = e
+		}
+		return nil
+	})
+	if broken == 0 && st != nil && prev.ID < st.HeadID {
+		broken = prev.ID + 1
+	}
+	return broken
+}
+
+// checkAuditLog dipanggil setelah unlock: rantai yang rusak / terpotong
+// dilaporkan ke frontend. Rantai yang utuh dijadikan checkpoint baru,
+// termasuk entry yang dicatat selama profil ini terkunci.
+func (a *App) checkAuditLog() {
+	if broken := a.verifyAuditChain(); broken != 0 {
+		log.Printf("audit: rantai log rusak di entry %d", broken)
+		a.emit("audit:broken", broken)
+		return
+	}
+	a.auditMu.Lock()
+	defer a.auditMu.Unlock()
+	db, st := a.auditState()
+	var last AuditEntry
+	a.configDB.Order("id desc").Limit(1).Find(&last)
+	if st != nil && last.ID > st.HeadID {
+		db.Model(st).Updates(map[string]interface{}{"head_id": last.ID, "head_hash": last.Hash})
+	}
+}
+
+// auditDetail: ringkas pesan error untuk kolom Detail.
+func auditDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	return strings.TrimSpace(err.Error())
+}
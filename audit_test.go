@@ -0,0 +1,101 @@
+package main
+
+import "testing"
+
+// newAuditTestApp membuat App dengan n entry audit.
+func newAuditTestApp(t *testing.T, n int) *App {
+	t.Helper()
+	a := newTestApp(t)
+	for i := 0; i < n; i++ {
+		a.audit(auditUnlock, "master", i%2 == 0, "")
+	}
+	return a
+}
+
+func auditBrokenAt(t *testing.T, a *App) uint {
+	t.Helper()
+	res, err := a.GetAuditLog(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Intact != (res.BrokenAt == 0) {
+		t.Fatalf("Intact = %v, BrokenAt = %d", res.Intact, res.BrokenAt)
+	}
+	return res.BrokenAt
+}
+
+func TestAuditAppendOnly(t *testing.T) {
+	a := newAuditTestApp(t, 3)
+	if got := auditBrokenAt(t, a); got != 0 {
+		t.Fatalf("rantai baru dilaporkan rusak di %d", got)
+	}
+	var e AuditEntry
+	a.configDB.First(&e, 2)
+	if err := a.configDB.Model(&e).Update("success", true).Error; err == nil {
+		t.Error("update entry audit tidak ditolak")
+	}
+	if err := a.configDB.Delete(&e).Error; err == nil {
+		t.Error("delete entry audit tidak ditolak")
+	}
+}
+
+// rewriteAuditChain menghitung ulang hash semua entry mulai fromID, seperti
+// penyerang yang mengubah library.db lalu menyambung ulang rantainya.
+func rewriteAuditChain(a *App, fromID uint) {
+	var entries []AuditEntry
+	a.configDB.Order("id asc").Find(&entries)
+	prevHash := ""
+	for _, e := range entries {
+		if e.ID >= fromID {
+			e.PrevHash = prevHash
+			e.Hash = e.computeHash()
+			a.configDB.Exec("UPDATE audit_entries SET prev_hash = ?, hash = ? WHERE id = ?", e.PrevHash, e.Hash, e.ID)
+		}
+		prevHash = e.Hash
+	}
+}
+
+// Entry yang diubah/dihapus langsung di database (melewati hook GORM)
+// terlihat sebagai rantai yang putus, termasuk jika rantainya disambung
+// ulang: checkpoint di library terenkripsi tidak ikut berubah.
+func TestAuditChainTamper(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(a *App)
+		want   uint
+	}{
+		{"detail diubah", func(a *App) {
+			a.configDB.Exec("UPDATE audit_entries SET detail = ? WHERE id = ?", "diubah", 3)
+		}, 3},
+		{"gagal jadi berhasil", func(a *App) {
+			a.configDB.Exec("UPDATE audit_entries SET success = ? WHERE id = ?", true, 2)
+		}, 2},
+		{"entry di tengah dihapus", func(a *App) {
+			a.configDB.Exec("DELETE FROM audit_entries WHERE id = ?", 3)
+		}, 4},
+		{"entry pertama dihapus", func(a *App) {
+			a.configDB.Exec("DELETE FROM audit_entries WHERE id = ?", 1)
+		}, 2},
+		{"entry terakhir dihapus", func(a *App) {
+			a.configDB.Exec("DELETE FROM audit_entries WHERE id >= ?", 4)
+		}, 4},
+		{"diubah lalu rantai disambung ulang", func(a *App) {
+			a.configDB.Exec("UPDATE audit_entries SET detail = ? WHERE id = ?", "diubah", 3)
+			rewriteAuditChain(a, 3)
+		}, 5},
+		{"dihapus lalu rantai disambung ulang", func(a *App) {
+			a.configDB.Exec("DELETE FROM audit_entries WHERE id = ?", 3)
+			a.configDB.Exec("UPDATE audit_entries SET id = id - 1 WHERE id > ?", 3)
+			rewriteAuditChain(a, 1)
+		}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAuditTestApp(t, 5)
+			tt.tamper(a)
+			if got := auditBrokenAt(t, a); got != tt.want {
+				t.Errorf("BrokenAt = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
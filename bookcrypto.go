@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// --- KUNCI PER BUKU (LOCKED BOOK) ---
+//
+// Buku yang dikunci punya DEK sendiri, dibungkus dengan password buku
+// (Book.KeySlot). Semua halaman dienkripsi ulang dengan kunci itu, jadi
+// membuka vault saja tidak cukup untuk membaca isi buku terkunci.
+//
+// Book.CryptoPending = true berarti enkripsi ulang belum selesai (misal
+// aplikasi tertutup). Akan dilanjutkan saat buku dibuka dengan password.
+
+func (a *App) bookKey(id uint) ([]byte, bool) {
+	a.keyMu.RLock()
+	defer a.keyMu.RUnlock()
+	key, ok := a.bookKeys[id]
+	return key, ok
+}
+
+func (a *App) setBookKey(id uint, key []byte) {
+	a.keyMu.Lock()
+	defer a.keyMu.Unlock()
+	a.bookKeys[id] = key
+}
+
+func (a *App) forgetBookKey(id uint) {
+	a.keyMu.Lock()
+	defer a.keyMu.Unlock()
+	if key, ok := a.bookKeys[id]; ok {
+		wipeBytes(key)
+		delete(a.bookKeys, id)
+	}
+}
+
+// bookReadKeys: salinan kunci untuk membaca file sebuah buku (wajib
+// wipeKeys). Kunci vault tetap disertakan untuk file yang belum selesai
+// dienkripsi ulang.
+func (a *App) bookReadKeys(book *Book) [][]byte {
+	keys := a.readKeys()
+	if key, ok := a.bookKey(book.ID); ok && key != nil {
+		keys = append([][]byte{append([]byte(nil), key...)}, keys...)
+	}
+	return keys
+}
+
+// bookWriteKey: salinan kunci untuk menulis halaman baru ke buku (sync
+// import). Seperti sessionKey, pemanggil wajib wipeBytes setelah selesai.
+func (a *App) bookWriteKey(book *Book) ([]byte, error) {
+	if !book.IsLocked || book.KeySlot == "" {
+		if key := a.sessionKey(); key != nil {
+			return key, nil
+		}
+		return nil, ErrVaultLocked
+	}
+	key, ok := a.bookKey(book.ID)
+	if !ok || key == nil {
+		return nil, fmt.Errorf("buku terkunci, buka dulu dengan password")
+	}
+	return append([]byte(nil), key...), nil
+}
+
+// reencryptBook mengenkripsi ulang semua halaman buku ke newKey.
+func (a *App) reencryptBook(book *Book, newKey []byte, oldKeys ...[]byte) error {
+	var failed int
+	var lastErr error
+	filepath.WalkDir(book.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if err := reencryptFile(path, newKey, oldKeys...); err != nil {
+			failed++
+			lastErr = err
+		}
+		return nil
+	})
+	if failed > 0 {
+		return fmt.Errorf("%d file gagal dienkripsi ulang: %w", failed, lastErr)
+	}
+	return nil
+}
+
+// sealBook mengenkripsi buku dengan kunci buku dan menghapus tanda pending.
+func (a *App) sealBook(book *Book, key []byte) error {
+	oldKeys := a.readKeys()
+	defer wipeKeys(oldKeys)
+	if err := a.reencryptBook(book, key, oldKeys...); err != nil {
+		return err
+	}
+	return a.libraryDB().Model(book).Update("crypto_pending", false).Error
+}
+
+// openBookKey membuka kunci buku dengan password. Buku yang dikunci sebelum
+// ada kunci per buku (hanya hash) langsung dienkripsi dengan kunci baru.
+func (a *App) openBookKey(book *Book, p string) ([]byte, error) {
+	if book.KeySlot != "" {
+		slot, err := parseKeySlot(book.KeySlot)
+		if err != nil {
+			return nil, err
+		}
+		return slot.unwrap(p)
+	}
+	return a.createBookKey(book, p)
+}
+
+// createBookKey membuat kunci buku baru. KeySlot disimpan SEBELUM file
+// dienkripsi ulang supaya kunci tidak hilang kalau proses terputus.
+func (a *App) createBookKey(book *Book, p string) ([]byte, error) {
+	key, err := newDataKey()
+	if err != nil {
+		return nil, err
+	}
+	slot, err := newKeySlot(p, key)
+	if err != nil {
+		return nil, err
+	}
+	book.IsLocked = true
+	book.PasswordHash = HashPassword(p)
+	book.KeySlot = marshalKeySlot(slot)
+	book.CryptoPending = true
+	err = a.libraryDB().Model(book).Updates(map[string]interface{}{
+		"is_locked":      true,
+		"password_hash":  book.PasswordHash,
+		"key_slot":       book.KeySlot,
+		"crypto_pending": true,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
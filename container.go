@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// --- FORMAT FILE TERENKRIPSI (CONTAINER) ---
+//
+// Layout v1:
+//
+//	magic    "GVLT"   (4 byte)
+//	version  uint8
+//	cipher   uint8    (1 = AES-256-GCM)
+//	keyID    8 byte   (HMAC-SHA256(key)[:8], bukan kuncinya)
+//	mimeLen  uint8
+//	mime     mimeLen byte
+//	plainLen uint64 (big endian)
+//	nonce || ciphertext   (header di atas dipakai sebagai AAD)
+//
+// Layout v2 (streaming, lihat stream.go) menambah setelah plainLen:
+//
+//	chunkSize   uint32 (big endian)
+//	noncePrefix 7 byte
+//	segmen[]    tiap segmen = AEAD(chunkSize byte plaintext) + 16 byte tag
+//
+// File tanpa magic dianggap format lama: nonce||ciphertext atau gambar polos.
+
+var containerMagic = []byte("GVLT")
+
+const (
+	containerV1     = 1
+	containerV2     = 2
+	cipherAES256GCM = 1
+	keyIDSize       = 8
+
+	// magic + version + cipher + keyID + mimeLen + mime(max) + plainLen + v2
+	maxHeaderSize = 4 + 1 + 1 + keyIDSize + 1 + 255 + 8 + 4 + noncePrefixSize
+)
+
+var (
+	ErrPlaintextFile = errors.New("file tidak terenkripsi")
+	ErrWrongKey      = errors.New("file dienkripsi dengan kunci lain")
+	ErrCorruptFile   = errors.New("file terenkripsi rusak")
+)
+
+type FileHeader struct {
+	Version  uint8
+	Cipher   uint8
+	KeyID    [keyIDSize]byte
+	MIME     string
+	PlainLen uint64
+
+	// Hanya v2
+	ChunkSize   uint32
+	NoncePrefix [noncePrefixSize]byte
+}
+
+// keyID adalah sidik kunci yang aman disimpan di header (tidak membocorkan kunci).
+func keyID(key []byte) [keyIDSize]byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("GalleryVault key id"))
+	var id [keyIDSize]byte
+	copy(id[:], mac.Sum(nil))
+	return id
+}
+
+func (h *FileHeader) marshal() []byte {
+	var buf bytes.Buffer
+	buf.Write(containerMagic)
+	buf.WriteByte(h.Version)
+	buf.WriteByte(h.Cipher)
+	buf.Write(h.KeyID[:])
+	buf.WriteByte(uint8(len(h.MIME)))
+	buf.WriteString(h.MIME)
+	binary.Write(&buf, binary.BigEndian, h.PlainLen)
+	if h.Version >= containerV2 {
+		binary.Write(&buf, binary.BigEndian, h.ChunkSize)
+		buf.Write(h.NoncePrefix[:])
+	}
+	return buf.Bytes()
+}
+
+// parseHeader membaca header dan mengembalikan panjangnya dalam byte.
+func parseHeader(data []byte) (*FileHeader, int, error) {
+	if !bytes.HasPrefix(data, containerMagic) {
+		return nil, 0, ErrPlaintextFile
+	}
+	r := bytes.NewReader(data[len(containerMagic):])
+	h := &FileHeader{}
+	var mimeLen uint8
+	if err := binary.Read(r, binary.BigEndian, &h.Version); err != nil {
+		return nil, 0, ErrCorruptFile
+	}
+	if err := binary.Read(r, binary.BigEndian, &h.Cipher); err != nil {
+		return nil, 0, ErrCorruptFile
+	}
+	if _, err := io.ReadFull(r, h.KeyID[:]); err != nil {
+		return nil, 0, ErrCorruptFile
+	}
+	if err := binary.Read(r, binary.BigEndian, &mimeLen); err != nil {
+		return nil, 0, ErrCorruptFile
+	}
+	mime := make([]byte, mimeLen)
+	if _, err := io.ReadFull(r, mime); err != nil {
+		return nil, 0, ErrCorruptFile
+	}
+	h.MIME = string(mime)
+	if err := binary.Read(r, binary.BigEndian, &h.PlainLen); err != nil {
+		return nil, 0, ErrCorruptFile
+	}
+	if h.Version >= containerV2 {
+		if err := binary.Read(r, binary.BigEndian, &h.ChunkSize); err != nil {
+			return nil, 0, ErrCorruptFile
+		}
+		if _, err := io.ReadFull(r, h.NoncePrefix[:]); err != nil {
+			return nil, 0, ErrCorruptFile
+		}
+	}
+	if h.Version > containerV2 || h.Cipher != cipherAES256GCM {
+		return nil, 0, ErrCorruptFile
+	}
+	if h.Version >= containerV2 && h.ChunkSize == 0 {
+		return nil, 0, ErrCorruptFile
+	}
+	return h, len(data) - r.Len(), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptData membungkus plain ke dalam container (v2) dengan kunci yang diberikan.
+func EncryptData(key, plain []byte, mime string) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := newEncryptWriter(&buf, key, mime, int64(len(plain)))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptData membuka container dengan kunci yang key ID-nya cocok.
+//
+// Error yang mungkin (cek dengan errors.Is):
+//   - ErrPlaintextFile: file gambar polos lama, data dikembalikan apa adanya
+//   - ErrWrongKey: tidak ada kunci yang cocok
+//   - ErrCorruptFile: header/ciphertext rusak
+func DecryptData(data []byte, keys ...[]byte) ([]byte, *FileHeader, error) {
+	h, n, err := parseHeader(data)
+	if errors.Is(err, ErrPlaintextFile) {
+		return decryptLegacy(data, keys)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if h.Version >= containerV2 {
+		dr, err := newDecryptReader(bytes.NewReader(data), int64(len(data)), keys...)
+		if err != nil {
+			return nil, h, err
+		}
+		plain, err := io.ReadAll(dr)
+		if err != nil {
+			return nil, h, err
+		}
+		return plain, h, nil
+	}
+
+	for _, k := range keys {
+		if k == nil || keyID(k) != h.KeyID {
+			continue
+		}
+		gcm, err := newGCM(k)
+		if err != nil {
+			return nil, nil, err
+		}
+		body := data[n:]
+		if len(body) < gcm.NonceSize() {
+			return nil, h, ErrCorruptFile
+		}
+		plain, err := gcm.Open(nil, body[:gcm.NonceSize()], body[gcm.NonceSize():], data[:n])
+		if err != nil || uint64(len(plain)) != h.PlainLen {
+			return nil, h, ErrCorruptFile
+		}
+		return plain, h, nil
+	}
+	return nil, h, ErrWrongKey
+}
+
+// decryptLegacy menangani file sebelum ada header: nonce||ciphertext
+// (kunci sesi atau kunci legacy), atau gambar yang tidak dienkripsi.
+func decryptLegacy(data []byte, keys [][]byte) ([]byte, *FileHeader, error) {
+	for _, k := range append(keys, legacyEncryptionKey) {
+		if k == nil {
+			continue
+		}
+		if plain, err := openGCM(k, data); err == nil {
+			return plain, legacyHeader(plain), nil
+		}
+	}
+	if h := legacyHeader(data); strings.HasPrefix(h.MIME, "image/") {
+		return data, h, ErrPlaintextFile
+	}
+	return nil, nil, ErrWrongKey
+}
+
+func legacyHeader(plain []byte) *FileHeader {
+	return &FileHeader{MIME: http.DetectContentType(plain), PlainLen: uint64(len(plain))}
+}
+
+// vaultFile adalah file vault yang sudah terbuka dan bisa di-Seek
+// (untuk http.ServeContent / Range request).
+type vaultFile struct {
+	io.ReadSeeker
+	Header *FileHeader
+	file   *os.File
+}
+
+func (v *vaultFile) Close() error { return v.file.Close() }
+
+// openVaultFile membuka file vault. Format v2 didekripsi per segmen langsung
+// dari disk; format lama dibaca penuh ke memori (ukurannya kecil).
+// Error mengikuti DecryptData; pada ErrPlaintextFile file tetap dikembalikan.
+func openVaultFile(path string, keys ...[]byte) (*vaultFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	dr, err := newDecryptReader(f, stat.Size(), keys...)
+	if err == nil {
+		return &vaultFile{ReadSeeker: dr, Header: dr.header, file: f}, nil
+	}
+	if !errors.Is(err, errNotStreaming) {
+		f.Close()
+		return nil, err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	plain, header, err := DecryptData(data, keys...)
+	if err != nil && !errors.Is(err, ErrPlaintextFile) {
+		f.Close()
+		return nil, err
+	}
+	return &vaultFile{ReadSeeker: bytes.NewReader(plain), Header: header, file: f}, err
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// legacyEncryptionKey adalah kunci lama yang dulu di-hardcode di binary.
+// Hanya dipakai untuk MEMBACA file vault lama, tidak pernah untuk menulis.
+var legacyEncryptionKey = []byte("GalleryVault_SecureKey_2026_IDN!")
+
+const dataKeySize = 32
+
+var (
+	ErrVaultLocked   = errors.New("vault terkunci")
+	ErrWrongPassword = errors.New("password salah")
+)
+
+// Parameter Argon2id default untuk membungkus kunci vault
+const (
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024 // KiB
+	kdfThreads = 4
+)
+
+// keySlot menyimpan Data Encryption Key (DEK) yang dibungkus (wrap) dengan
+// kunci turunan password. Disimpan sebagai JSON di GlobalConfig.
+type keySlot struct {
+	Salt    string `json:"salt"`
+	Time    uint32 `json:"t"`
+	Memory  uint32 `json:"m"`
+	Threads uint8  `json:"p"`
+	Wrapped string `json:"wrapped"` // nonce || ciphertext (base64)
+}
+
+func (s *keySlot) deriveKEK(secret string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(s.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("salt rusak: %w", err)
+	}
+	return argon2.IDKey([]byte(secret), salt, s.Time, s.Memory, s.Threads, dataKeySize), nil
+}
+
+// newKeySlot membungkus dek dengan kunci turunan secret (Argon2id + AES-GCM).
+func newKeySlot(secret string, dek []byte) (*keySlot, error) {
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	slot := &keySlot{
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Time:    kdfTime,
+		Memory:  kdfMemory,
+		Threads: kdfThreads,
+	}
+	kek, err := slot.deriveKEK(secret)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(kek)
+
+	wrapped, err := sealGCM(kek, dek)
+	if err != nil {
+		return nil, err
+	}
+	slot.Wrapped = base64.StdEncoding.EncodeToString(wrapped)
+	return slot, nil
+}
+
+// unwrap membuka DEK. Mengembalikan ErrWrongPassword jika secret salah.
+func (s *keySlot) unwrap(secret string) ([]byte, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(s.Wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("key slot rusak: %w", err)
+	}
+	kek, err := s.deriveKEK(secret)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(kek)
+
+	dek, err := openGCM(kek, wrapped)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return dek, nil
+}
+
+func (a *App) loadKeySlot(name string) *keySlot {
+	raw := a.getConfig(name)
+	if raw == "" {
+		return nil
+	}
+	slot, err := parseKeySlot(raw)
+	if err != nil {
+		return nil
+	}
+	return slot
+}
+
+func parseKeySlot(raw string) (*keySlot, error) {
+	var slot keySlot
+	if err := json.Unmarshal([]byte(raw), &slot); err != nil {
+		return nil, fmt.Errorf("key slot rusak: %w", err)
+	}
+	return &slot, nil
+}
+
+func (a *App) saveKeySlot(name string, slot *keySlot) {
+	a.setConfig(name, marshalKeySlot(slot))
+}
+
+func marshalKeySlot(slot *keySlot) string {
+	raw, _ := json.Marshal(slot)
+	return string(raw)
+}
+
+// newDataKey membuat DEK acak untuk vault / buku.
+func newDataKey() ([]byte, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func wipeKeys(keys [][]byte) {
+	for _, k := range keys {
+		wipeBytes(k)
+	}
+}
+
+func sealGCM(key, plain []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func openGCM(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("data terlalu pendek")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// --- SESSION KEY ---
+
+// sessionKey mengembalikan SALINAN DEK vault yang sedang terbuka (nil jika
+// terkunci). Kunci sesi di-wipe saat vault dikunci, jadi pemanggil memakai
+// salinannya sendiri dan wajib menghapusnya dengan wipeBytes setelah selesai.
+func (a *App) sessionKey() []byte {
+	a.keyMu.RLock()
+	defer a.keyMu.RUnlock()
+	if a.vaultKey == nil {
+		return nil
+	}
+	return append([]byte(nil), a.vaultKey...)
+}
+
+func (a *App) setSessionKey(key []byte) {
+	a.keyMu.Lock()
+	defer a.keyMu.Unlock()
+	if a.vaultKey != nil && (key == nil || &a.vaultKey[0] != &key[0]) {
+		wipeBytes(a.vaultKey)
+	}
+	a.vaultKey = key
+}
+
+// readKeys mengembalikan SALINAN kunci yang boleh dipakai untuk MEMBACA
+// file: kunci sesi, ditambah kunci lama selama rotasi masih berjalan.
+// Seperti sessionKey, pemanggil wajib wipeKeys setelah selesai.
+func (a *App) readKeys() [][]byte {
+	a.keyMu.RLock()
+	defer a.keyMu.RUnlock()
+	keys := [][]byte{append([]byte(nil), a.vaultKey...)}
+	if a.retiredKey != nil {
+		keys = append(keys, append([]byte(nil), a.retiredKey...))
+	}
+	return keys
+}
+
+func (a *App) IsVaultUnlocked() bool {
+	a.keyMu.RLock()
+	defer a.keyMu.RUnlock()
+	return a.vaultKey != nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// --- DECOY VAULT (DURESS PASSWORD) ---
+//
+// Password kedua yang juga diterima VerifyPassword, tapi membuka library
+// lain yang isinya tidak berbahaya:
+//
+//	GalleryVault/slot1/library.vault, slot1/vault/, slot1/cache/
+//
+// Semua key GlobalConfig milik profil decoy memakai prefix "slot1:"
+// (slot1:master_hash, slot1:master_keyslot, slot1:hidden_hash, ...), jadi
+// mengganti password / Hidden Zone dari dalam decoy tidak menyentuh vault
+// asli. Setelah terbuka, UI tidak bisa membedakan keduanya. Nama folder &
+// prefix sengaja netral: isi GalleryVault/ tidak boleh menyebut "decoy".
+
+const (
+	decoyPrefix = "slot1:"
+	decoyDir    = "slot1"
+
+	// Nama lama (sebelum dinetralkan), dimigrasi oleh migrateDecoyNames
+	legacyDecoyPrefix = "decoy:"
+	legacyDecoyDir    = "decoy"
+)
+
+// errPasswordInUse: password baru sama dengan password profil lain. Pesannya
+// sengaja tidak menyebut profil mana.
+var errPasswordInUse = errors.New("password tidak bisa dipakai")
+
+// dummyPasswordHash dipakai jika decoy belum dibuat, supaya VerifyPassword
+// selalu menghitung dua hash (waktu respon tidak membocorkan adanya decoy).
+const dummyPasswordHash = "$argon2id$v=19$m=65536,t=3,p=4$aW/MWnLFlh0PZuaCo+J/lw$1tF9zSkysmPLPQwSJ4WX0h3K/3O0t21JV3Qa0A5v0kU"
+
+// cfg: nama key GlobalConfig untuk profil yang sedang aktif.
+func (a *App) cfg(key string) string {
+	if a.decoyActive {
+		return decoyPrefix + key
+	}
+	return key
+}
+
+// profileDir: folder data profil aktif (library, vault, cache).
+func (a *App) profileDir() string {
+	if a.decoyActive {
+		return filepath.Join(a.appDataDir, decoyDir)
+	}
+	return a.appDataDir
+}
+
+// migrateDecoyNames mengganti nama key GlobalConfig & folder decoy lama
+// ("decoy:", GalleryVault/decoy) ke nama netral. Dipanggil saat startup.
+func (a *App) migrateDecoyNames() {
+	err := a.configDB.Exec("UPDATE global_configs SET key = ? || substr(key, ?) WHERE key LIKE ?",
+		decoyPrefix, len(legacyDecoyPrefix)+1, legacyDecoyPrefix+"%").Error
+	if err != nil {
+		log.Printf("profil: gagal migrasi config: %v", err)
+	}
+	oldDir := filepath.Join(a.appDataDir, legacyDecoyDir)
+	newDir := filepath.Join(a.appDataDir, decoyDir)
+	if _, err := os.Stat(oldDir); err == nil {
+		if _, err := os.Stat(newDir); os.IsNotExist(err) {
+			if err := os.Rename(oldDir, newDir); err != nil {
+				log.Printf("profil: gagal migrasi folder: %v", err)
+			}
+		}
+	}
+}
+
+func (a *App) useProfile(decoy bool) {
+	a.decoyActive = decoy
+	a.vaultDir = filepath.Join(a.profileDir(), "vault")
+	os.MkdirAll(a.vaultDir, 0755)
+}
+
+// checkMasterPassword mencocokkan p dengan password asli DAN password decoy.
+// Hasil: ok, dan decoy = true jika yang cocok adalah password decoy.
+func (a *App) checkMasterPassword(p string) (ok, decoy bool) {
+	if a.retryAfter("master") > 0 {
+		a.audit(auditUnlock, "master", false, "throttled")
+		return false, false
+	}
+	decoyHash := a.getConfig(decoyPrefix + "master_hash")
+	hasDecoy := decoyHash != ""
+	if !hasDecoy {
+		decoyHash = dummyPasswordHash
+	}
+	okReal, upReal := CheckPassword(p, a.getConfig("master_hash"))
+	okDecoy, upDecoy := CheckPassword(p, decoyHash)
+	okDecoy = okDecoy && hasDecoy && !okReal
+
+	a.recordAttempt("master", okReal || okDecoy)
+	// Profil tidak dicatat: log ada di library.db dan tidak boleh membocorkan decoy
+	a.audit(auditUnlock, "master", okReal || okDecoy, "")
+	switch {
+	case okReal && upReal:
+		a.setConfig("master_hash", HashPassword(p))
+	case okDecoy && upDecoy:
+		a.setConfig(decoyPrefix+"master_hash", HashPassword(p))
+	}
+	return okReal || okDecoy, okDecoy
+}
+
+// HasDecoyPassword selalu false dari dalam decoy: profil decoy tidak boleh
+// tahu ada profil lain.
+func (a *App) HasDecoyPassword() bool {
+	if a.decoyActive {
+		return false
+	}
+	return a.getConfig(decoyPrefix+"master_hash") != ""
+}
+
+// usedByOtherProfile: p adalah password profil lain (asli untuk decoy, decoy
+// untuk asli). Password kedua profil tidak boleh sama.
+func (a *App) usedByOtherProfile(p string, decoy bool) bool {
+	other := decoyPrefix + "master_hash"
+	if decoy {
+		other = "master_hash"
+	}
+	same, _ := CheckPassword(p, a.getConfig(other))
+	return same
+}
+
+// SetDecoyPassword membuat (atau membuat ulang) vault decoy. Hanya dari
+// vault asli yang sedang terbuka; mengganti password decoy = decoy baru,
+// isi decoy lama dihapus.
+func (a *App) SetDecoyPassword(p string) error {
+	if p == "" {
+		return fmt.Errorf("password tidak boleh kosong")
+	}
+	if !a.IsVaultUnlocked() {
+		return ErrVaultLocked
+	}
+	if a.decoyActive {
+		// Dari dalam decoy pura-pura berhasil, supaya UI tetap sama
+		return nil
+	}
+	if ok, _ := CheckPassword(p, a.getConfig("master_hash")); ok {
+		return fmt.Errorf("password decoy tidak boleh sama dengan master password")
+	}
+
+	key, err := newDataKey()
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(key)
+	slot, err := newKeySlot(p, key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(a.appDataDir, decoyDir)
+	if err := secureRemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "vault"), 0755); err != nil {
+		return err
+	}
+	for _, k := range []string{
+		"rotation_keyslot", "legacy_vault", "hidden_hash",
+		"recovery_pub", "recovery_box", "recovery_rotation_box",
+	} {
+		a.deleteConfig(decoyPrefix + k)
+	}
+	a.saveKeySlot(decoyPrefix+"master_keyslot", slot)
+	a.setConfig(decoyPrefix+"master_hash", HashPassword(p))
+	return nil
+}
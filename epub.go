@@ -0,0 +1,319 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- IMPORT EPUB ---
+//
+// EPUB fixed-layout (manga, artbook) = zip berisi halaman XHTML yang masing-
+// masing menampilkan satu gambar. Urutan baca diambil dari spine di OPF,
+// lalu gambar yang direferensikan tiap halaman (<img>, <svg:image>) diambil
+// berurutan dan dinomori 0001, 0002, ... Judul, pengarang, series dan
+// deskripsi diambil dari metadata OPF.
+
+var errEPUBNoImages = errors.New("EPUB tidak berisi gambar halaman (EPUB teks belum didukung)")
+
+type opfPackage struct {
+	Metadata struct {
+		Titles       []string  `xml:"title"`
+		Creators     []opfMeta `xml:"creator"`
+		Descriptions []string  `xml:"description"`
+		Languages    []string  `xml:"language"`
+		Meta         []opfMeta `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine struct {
+		Direction string `xml:"page-progression-direction,attr"`
+		Items     []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// opfMeta dipakai untuk <dc:creator> dan <meta> (EPUB2: name/content,
+// EPUB3: property + isi elemen).
+type opfMeta struct {
+	ID       string `xml:"id,attr"`
+	Name     string `xml:"name,attr"`
+	Content  string `xml:"content,attr"`
+	Property string `xml:"property,attr"`
+	Refines  string `xml:"refines,attr"`
+	Role     string `xml:"role,attr"`
+	Value    string `xml:",chardata"`
+}
+
+func scanEPUB(epubPath string) (*importSource, error) {
+	zr, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka EPUB: %w", err)
+	}
+	src, err := readEPUB(&zr.Reader)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	src.closer = zr
+	return src, nil
+}
+
+func readEPUB(zr *zip.Reader) (*importSource, error) {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	opfPath, err := epubRootFile(files)
+	if err != nil {
+		return nil, err
+	}
+	var opf opfPackage
+	if err := readZipXML(files[opfPath], &opf); err != nil {
+		return nil, fmt.Errorf("OPF tidak valid: %w", err)
+	}
+	base := path.Dir(opfPath)
+
+	type manifestItem struct{ href, mediaType string }
+	items := map[string]manifestItem{}
+	var coverHref string
+	for _, it := range opf.Manifest {
+		href := resolveEPUBHref(base, it.Href)
+		items[it.ID] = manifestItem{href, it.MediaType}
+		if strings.Contains(" "+it.Properties+" ", " cover-image ") {
+			coverHref = href
+		}
+	}
+	for _, m := range opf.Metadata.Meta {
+		if m.Name == "cover" && coverHref == "" {
+			coverHref = items[m.Content].href
+		}
+	}
+
+	// Gambar sesuai urutan spine (gambar yang sama hanya diambil sekali)
+	var hrefs []string
+	seen := map[string]bool{}
+	addImage := func(href string) {
+		// Format dicek dari isinya saat scan (classifyPages)
+		if f := files[href]; f != nil && !seen[href] {
+			seen[href] = true
+			hrefs = append(hrefs, href)
+		}
+	}
+	for _, ref := range opf.Spine.Items {
+		item, ok := items[ref.IDRef]
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(item.mediaType, "image/") {
+			addImage(item.href)
+			continue
+		}
+		for _, src := range epubPageImages(files[item.href]) {
+			addImage(resolveEPUBHref(path.Dir(item.href), src))
+		}
+	}
+	if coverHref != "" && !seen[coverHref] && files[coverHref] != nil {
+		// Cover yang tidak ada di spine ditaruh paling depan
+		hrefs = append([]string{coverHref}, hrefs...)
+	}
+	if len(hrefs) == 0 {
+		return nil, errEPUBNoImages
+	}
+
+	pages := make([]importPage, len(hrefs))
+	for i, href := range hrefs {
+		pages[i] = importPage{
+			Rel:  fmt.Sprintf("%04d%s", i+1, strings.ToLower(path.Ext(href))),
+			Open: files[href].Open,
+		}
+	}
+	return &importSource{Pages: pages, Meta: opf.metadata(), Embedded: true}, nil
+}
+
+// epubRootFile membaca META-INF/container.xml untuk lokasi file OPF.
+func epubRootFile(files map[string]*zip.File) (string, error) {
+	var container struct {
+		RootFiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := readZipXML(files["META-INF/container.xml"], &container); err == nil {
+		for _, rf := range container.RootFiles {
+			if files[rf.FullPath] != nil {
+				return rf.FullPath, nil
+			}
+		}
+	}
+	// container.xml rusak: cari file .opf langsung
+	for name := range files {
+		if strings.EqualFold(path.Ext(name), ".opf") {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("bukan file EPUB (OPF tidak ditemukan)")
+}
+
+func readZipXML(f *zip.File, v any) error {
+	if f == nil {
+		return fmt.Errorf("file tidak ditemukan")
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	dec := xml.NewDecoder(io.LimitReader(r, 16<<20))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	return dec.Decode(v)
+}
+
+// epubPageImages: src semua gambar di satu halaman XHTML/SVG, berurutan.
+func epubPageImages(f *zip.File) []string {
+	if f == nil {
+		return nil
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+	dec := xml.NewDecoder(io.LimitReader(r, 16<<20))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	var srcs []string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return srcs
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(el.Name.Local) {
+		case "img":
+			srcs = appendAttr(srcs, el, "src")
+		case "image":
+			// <svg:image xlink:href="..."> atau href (SVG 2)
+			srcs = appendAttr(srcs, el, "href")
+		}
+	}
+}
+
+func appendAttr(list []string, el xml.StartElement, name string) []string {
+	for _, attr := range el.Attr {
+		if strings.EqualFold(attr.Name.Local, name) && attr.Value != "" {
+			return append(list, attr.Value)
+		}
+	}
+	return list
+}
+
+// resolveEPUBHref mengubah href relatif (terhadap dir) menjadi nama entry zip.
+func resolveEPUBHref(dir, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	if u, err := url.PathUnescape(href); err == nil {
+		href = u
+	}
+	if dir == "." {
+		dir = ""
+	}
+	return strings.TrimPrefix(path.Clean(path.Join(dir, href)), "/")
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+func (opf *opfPackage) metadata() *bookMetadata {
+	md := opf.Metadata
+	meta := &bookMetadata{}
+	if len(md.Titles) > 0 {
+		meta.Title = strings.TrimSpace(md.Titles[0])
+	}
+	if len(md.Languages) > 0 {
+		meta.Language = strings.TrimSpace(md.Languages[0])
+	}
+	switch opf.Spine.Direction {
+	case readingLTR, readingRTL:
+		meta.Direction = opf.Spine.Direction
+	}
+	if len(md.Descriptions) > 0 {
+		// Deskripsi EPUB sering berupa HTML
+		desc := htmlTag.ReplaceAllString(md.Descriptions[0], " ")
+		meta.Description = strings.Join(strings.Fields(html.UnescapeString(desc)), " ")
+	}
+
+	// Pengarang: creator dengan role "aut" (EPUB2 opf:role atau EPUB3
+	// <meta refines="#id" property="role">), atau creator pertama.
+	roles := map[string]string{}
+	for _, m := range md.Meta {
+		if m.Property == "role" {
+			roles[strings.TrimPrefix(m.Refines, "#")] = strings.TrimSpace(m.Value)
+		}
+	}
+	var authors, artists []string
+	for _, c := range md.Creators {
+		role := c.Role
+		if r, ok := roles[c.ID]; ok {
+			role = r
+		}
+		name := strings.TrimSpace(c.Value)
+		switch {
+		case name == "":
+		case role == "" || role == "aut":
+			authors = append(authors, name)
+		case role == "ill" || role == "art":
+			artists = append(artists, name)
+		}
+	}
+	if len(authors) == 0 && len(md.Creators) > 0 {
+		authors = append(authors, strings.TrimSpace(md.Creators[0].Value))
+	}
+	meta.Author = joinNames(authors)
+	meta.Artist = joinNames(artists)
+
+	// Series: calibre:series (EPUB2) atau belongs-to-collection (EPUB3),
+	// nomor urutnya dipakai sebagai volume
+	var collection string
+	for _, m := range md.Meta {
+		switch {
+		case m.Name == "calibre:series" && meta.Series == "":
+			meta.Series = strings.TrimSpace(m.Content)
+		case m.Name == "calibre:series_index" && meta.Volume == 0:
+			meta.Volume = int(parseFloatOr(m.Content, 0))
+		case m.Property == "belongs-to-collection" && meta.Series == "":
+			meta.Series = strings.TrimSpace(m.Value)
+			collection = m.ID
+		}
+	}
+	for _, m := range md.Meta {
+		if collection != "" && m.Property == "group-position" && strings.TrimPrefix(m.Refines, "#") == collection {
+			meta.Volume = int(parseFloatOr(m.Value, float64(meta.Volume)))
+		}
+	}
+	return meta
+}
+
+func parseFloatOr(s string, def float64) float64 {
+	if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+		return f
+	}
+	return def
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestEPUB membuat EPUB fixed-layout satu halaman dengan dc:title title.
+func writeTestEPUB(t *testing.T, title string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "buku.epub")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	add := func(name string, data []byte) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+
+	add("mimetype", []byte("application/epub+zip"))
+	add("META-INF/container.xml", []byte(`<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`))
+	add("OEBPS/content.opf", []byte(fmt.Sprintf(`<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>%s</dc:title></metadata>
+  <manifest><item id="p1" href="p1.png" media-type="image/png"/></manifest>
+  <spine><itemref idref="p1"/></spine>
+</package>`, title)))
+	add("OEBPS/p1.png", img.Bytes())
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Judul dari OPF dipakai sebagai nama folder buku: apa pun isinya, folder
+// buku tetap satu tingkat di bawah folder vault.
+func TestImportEPUBTitleStaysInVault(t *testing.T) {
+	for _, title := range []string{"..", "../../..", ".", "Fate/stay night", `a\b`, " "} {
+		t.Run(title, func(t *testing.T) {
+			a := newTestApp(t)
+			src := writeTestEPUB(t, title)
+			report := a.importBook(context.Background(), newImportTracker(a, ""), archiveBookName(src), src, false, ImportOptions{})
+			if report.Error != "" || report.Imported != 1 {
+				t.Fatalf("import: %+v", report)
+			}
+			var book Book
+			if err := a.db.First(&book).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !a.inVault(book.Path) {
+				t.Fatalf("folder buku %q di luar vault %q", book.Path, a.vaultDir)
+			}
+			if files, _ := os.ReadDir(book.Path); len(files) != 1 {
+				t.Fatalf("folder buku berisi %d file, want 1", len(files))
+			}
+
+			if err := a.DeleteBook(book.Title); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(a.vaultDir); err != nil {
+				t.Fatalf("folder vault ikut terhapus: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(a.appDataDir, "library.db")); err != nil {
+				t.Fatalf("data di luar vault ikut terhapus: %v", err)
+			}
+		})
+	}
+}
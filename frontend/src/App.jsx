@@ -1,12 +1,15 @@
 import { useState, useEffect, useCallback, useMemo, useRef } from 'react';
 // ... (Import Wails functions TETAP SAMA) ...
 import {
-    CreateBook, GetBooks, GetChapters, GetImagesInChapter, SelectFolder, HasPassword,
+    StartImport, StartBatchImport, CancelImport, GetBooks, GetChapters, GetImagesInChapter, SelectFolder, SelectArchive, HasPassword,
     SetMasterPassword, VerifyPassword, DeleteBook, UpdateBookMetadata, SetBookCover,
     LockBook, UnlockBook, VerifyBookPassword, ToggleHiddenZone, IsHiddenZoneActive, LockHiddenZone,
-    HasHiddenZonePassword, SetHiddenZonePassword, BatchImportBooks, ToggleBookFavorite, UpdateBookProgress,
-    GetAllSeries, CreateSeries, AddBookToSeries, RemoveBookFromSeries, DeleteSeries
+    HasHiddenZonePassword, SetHiddenZonePassword, ToggleBookFavorite, UpdateBookProgress,
+    GetAllSeries, CreateSeries, AddBookToSeries, RemoveBookFromSeries, DeleteSeries,
+    GetSessionSettings, SetSessionSettings, ReportActivity, WindowHidden, ClearThumbnailCache, SetDecoyPassword, Panic, GetAuditLog,
+    RecoverWithKey, RegenerateRecoveryKey, GetImageSettings, SetImageSettings
 } from '../wailsjs/go/main/App';
+import { EventsOn } from '../wailsjs/runtime/runtime';
 import './App.css';
 import Reader from './components/Reader';
 import Toast from './components/Toast'; // [BARU]
@@ -46,6 +49,9 @@ function App() {
     const [isAdmin, setIsAdmin] = useState(false);
     const [hasPasswordSetup, setHasPasswordSetup] = useState(null);
     const [passwordInput, setPasswordInput] = useState('');
+    const [recoveryMode, setRecoveryMode] = useState(false);
+    const [recoveryInput, setRecoveryInput] = useState('');
+    const [shownRecoveryKey, setShownRecoveryKey] = useState('');
     const [showLoginModal, setShowLoginModal] = useState(false);
 
     const [view, setView] = useState('library');
@@ -62,6 +68,8 @@ function App() {
     const [adminViewMode, setAdminViewMode] = useState('stats');
     const [allTags, setAllTags] = useState([]);
     const [tagSearch, setTagSearch] = useState('');
+    const [auditLog, setAuditLog] = useState(null);
+    const [auditOnlyFailures, setAuditOnlyFailures] = useState(false);
 
     const [currentBookObj, setCurrentBookObj] = useState(null);
     const [chapters, setChapters] = useState([]);
@@ -94,6 +102,13 @@ function App() {
     const [editMaskCover, setEditMaskCover] = useState(false);
     const [showSettings, setShowSettings] = useState(false);
     const [settingsPassInput, setSettingsPassInput] = useState('');
+    const [sessionSettings, setSessionSettingsState] = useState({ idle_minutes: 10, lock_on_blur: false });
+    // [BARU] Pengaturan kompresi halaman & thumbnail (GlobalConfig)
+    const [imageSettings, setImageSettingsState] = useState(null);
+    const [panicDestroyKey, setPanicDestroyKey] = useState(localStorage.getItem('gv_panicDestroy') === '1');
+    const [importJobs, setImportJobs] = useState({}); // [BARU] job_id -> progress import di background
+    const handleImportDoneRef = useRef(null); // listener event dipasang sekali, handler-nya selalu yang terbaru
+    const finishedImportsRef = useRef(new Set()); // job yang selesai sebelum StartImport sempat return
 
     // [BARU] Simpan preferensi setiap kali berubah
     useEffect(() => {
@@ -111,6 +126,46 @@ function App() {
         check(); 
     }, []);
 
+    // [BARU] Auto-lock: backend mengunci sesi saat idle / jendela tidak fokus
+    useEffect(() => {
+        GetSessionSettings().then(s => s && setSessionSettingsState(s));
+        GetImageSettings().then(s => s && setImageSettingsState(s));
+        const off = EventsOn('vault:locked', (reason) => {
+            setIsAdmin(false); setHiddenZoneActive(false); setBooks([]);
+            setCurrentBookObj(null); setActiveSeries(null); setView('library');
+            setShowLoginModal(true);
+            addToast(reason === 'idle' ? "Vault dikunci otomatis (idle)" : "Vault Terkunci", 'info');
+        });
+        let last = 0;
+        const onActivity = () => { const now = Date.now(); if (now - last > 15000) { last = now; ReportActivity(); } };
+        const onHidden = () => { if (document.hidden) WindowHidden(); };
+        const events = ['mousemove', 'mousedown', 'keydown', 'wheel', 'touchstart'];
+        events.forEach(e => window.addEventListener(e, onActivity, { passive: true }));
+        window.addEventListener('blur', WindowHidden);
+        document.addEventListener('visibilitychange', onHidden);
+        const offProgress = EventsOn('import:progress', (p) => setImportJobs(jobs => ({...jobs, [p.job_id]: p})));
+        const offDone = EventsOn('import:done', (result) => handleImportDoneRef.current(result));
+        return () => {
+            off(); offProgress(); offDone();
+            events.forEach(e => window.removeEventListener(e, onActivity));
+            window.removeEventListener('blur', WindowHidden);
+            document.removeEventListener('visibilitychange', onHidden);
+        };
+    }, []);
+
+    // [BARU] Panic hotkey: Ctrl+Shift+X langsung mengunci semuanya
+    useEffect(() => {
+        localStorage.setItem('gv_panicDestroy', panicDestroyKey ? '1' : '0');
+        const onKey = (e) => {
+            if (e.ctrlKey && e.shiftKey && e.key.toLowerCase() === 'x') {
+                e.preventDefault();
+                Panic(panicDestroyKey);
+            }
+        };
+        window.addEventListener('keydown', onKey);
+        return () => window.removeEventListener('keydown', onKey);
+    }, [panicDestroyKey]);
+
     // --- FETCH FUNCTIONS ---
     const fetchSeries = useCallback(async () => {
         const res = await GetAllSeries();
@@ -223,39 +278,81 @@ function App() {
         }
     };
 
+    // [BARU] Tanya apakah judul dari ComicInfo.xml / info.json / EPUB dipakai,
+    // dan profil penyimpanan halaman (sync selalu ikut profil bukunya)
+    const storageProfiles = { '1': 'compressed', '2': 'lossless', '3': 'original' };
+    const askImportOptions = () => ({
+        prefer_sidecar: confirm("Pakai judul dari metadata (ComicInfo.xml / info.json / EPUB) jika ada?\n\nJika tidak, nama folder/file yang dipakai. Penulis, series, tag, dll. dari metadata tetap dipakai."),
+        storage_profile: storageProfiles[prompt("Mode penyimpanan:\n\n1. Compressed (JPEG, ukuran & kualitas sesuai Settings)\n2. Lossless (PNG, resolusi asli)\n3. Original (file asli, hanya dienkripsi)", "1")] || 'compressed',
+    });
+
+    // [BARU] Import berjalan di background, progress lewat event "import:progress"
+    const startImportJob = async (start) => {
+        try {
+            const id = await start();
+            if (finishedImportsRef.current.has(id)) return;
+            setImportJobs(jobs => ({...jobs, [id]: jobs[id] || { job_id: id, book: '', done: 0, total: 0, eta_seconds: -1 }}));
+            addToast("Import dimulai", 'info');
+        } catch (err) { addToast(err, 'error'); }
+    };
+
     const handleAddBook = async () => {
+        const source = prompt("Import dari:\n\n1. Folder\n2. File (CBZ/ZIP/PDF/EPUB)", "1");
+        if (source === '2') {
+            const file = await SelectArchive();
+            if (!file) return;
+            const name = prompt("Nama Buku:", file.split(/[\\/]/).pop().replace(/\.[^.]+$/, ''));
+            if (!name) return;
+            const opts = askImportOptions();
+            startImportJob(() => StartImport(name, file, false, opts));
+            return;
+        }
+        if (source !== '1') return;
         const path = await SelectFolder();
         if (!path) return;
         const folderName = path.split(/[\\/]/).pop();
-        const choice = prompt(`Folder: "${folderName}"\n\n1. Import Single Book\n2. Batch Import`, "1");
+        const choice = prompt(`Folder: "${folderName}"\n\n1. Import Single Book\n2. Batch Import (subfolder & file CBZ/ZIP/PDF/EPUB)`, "1");
 
-        setIsLoading(true);
         if (choice === '1') {
             const name = prompt("Nama Buku:", folderName);
             if (name) {
-                const res = await CreateBook(name, path, false);
-                addToast(res, 'info');
+                const opts = askImportOptions();
+                startImportJob(() => StartImport(name, path, false, opts));
             }
         } else if (choice === '2') {
             if (confirm(`Import semua di "${folderName}"?`)) {
-                const logs = await BatchImportBooks(path);
-                alert(logs.join('\n')); // Batch log terlalu panjang buat toast, keep alert/modal
+                const opts = askImportOptions();
+                startImportJob(() => StartBatchImport(path, opts));
             }
         }
-        setIsLoading(false);
-        fetchBooks(true);
     };
 
     const handleUpdate = async (e, name) => { 
         e.stopPropagation(); 
         const path = await SelectFolder(); 
         if(!path) return; 
-        setIsLoading(true); 
-        await CreateBook(name, path, true); 
-        setIsLoading(false); 
-        addToast("Buku diperbarui", 'success');
-        fetchBooks(true); 
+        startImportJob(() => StartImport(name, path, true, {prefer_sidecar: false}));
+    };
+
+    // [BARU] Laporan akhir job import: ringkasan + daftar file yang gagal / dilewati
+    const handleImportDone = (result) => {
+        finishedImportsRef.current.add(result.job_id);
+        setImportJobs(jobs => { const next = {...jobs}; delete next[result.job_id]; return next; });
+        const reports = result.reports || [];
+        const ok = reports.filter(r => !r.error && !r.canceled);
+        const lines = [];
+        reports.forEach(r => {
+            if (r.error) lines.push(`Skip [${r.book}]: ${r.error}`);
+            (r.failed || []).forEach(f => lines.push(`[${r.book}] ${f.file}: ${f.error}`));
+            (r.skipped || []).forEach(f => lines.push(`[${r.book}] ${f.file}: dilewati, ${f.error}`));
+        });
+        if (result.canceled) addToast("Import dibatalkan", 'info');
+        else addToast(`Import selesai: ${ok.length} buku, ${ok.reduce((n, r) => n + r.imported, 0)} gambar`, lines.length ? 'info' : 'success');
+        if (lines.length) alert(`Gagal / dilewati (${lines.length}):\n\n` + lines.join('\n')); // Terlalu panjang buat toast
+        fetchBooks(true);
     };
+    handleImportDoneRef.current = handleImportDone;
+
 
     const handleDelete = async (e, name) => { 
         e.stopPropagation(); 
@@ -325,13 +422,26 @@ function App() {
     const handleAdminLogin = async (e) => {
         e.preventDefault();
         if (!hasPasswordSetup) {
-            await SetMasterPassword(passwordInput);
-            setHasPasswordSetup(true); setIsAdmin(true); setPasswordInput(''); setShowLoginModal(false);
-            addToast("Admin Password Dibuat!", 'success');
+            try {
+                const recoveryKey = await SetMasterPassword(passwordInput);
+                setHasPasswordSetup(true); setIsAdmin(true); setPasswordInput(''); setShowLoginModal(false);
+                fetchBooks(true);
+                addToast("Admin Password Dibuat!", 'success');
+                if (recoveryKey) setShownRecoveryKey(recoveryKey);
+            } catch (err) { addToast(String(err), 'error'); }
+            return;
+        }
+        if (recoveryMode) {
+            try {
+                await RecoverWithKey(recoveryInput, passwordInput);
+                setIsAdmin(true); setPasswordInput(''); setRecoveryInput(''); setRecoveryMode(false); setShowLoginModal(false);
+                fetchBooks(true);
+                addToast("Password Direset dengan Recovery Key", 'success');
+            } catch (err) { addToast(String(err), 'error'); }
             return;
         }
         const ok = await VerifyPassword(passwordInput);
-        if (ok) { setIsAdmin(true); setPasswordInput(''); setShowLoginModal(false); addToast("Login Admin Berhasil", 'success'); } 
+        if (ok) { setIsAdmin(true); setPasswordInput(''); setShowLoginModal(false); fetchBooks(true); addToast("Login Admin Berhasil", 'success'); } 
         else { addToast("Password Salah!", 'error'); }
     };
     
@@ -344,8 +454,19 @@ function App() {
     const handleLockHiddenZone = async () => { await LockHiddenZone(); setHiddenZoneActive(false); addToast("Hidden Zone Terkunci", 'info'); };
     const handleUnlockAction = async () => { if(confirm("Hapus proteksi?")) { await UnlockBook(editingBook.name); setEditingBook(null); fetchBooks(true); addToast("Proteksi dihapus", 'success'); } };
     const handleReaderSetCover = async (filename) => { if(!currentBookObj) return; let f = filename; if (currentChapter) f = currentChapter + "/" + filename; try { await SetBookCover(currentBookObj.name, f); addToast("Cover berhasil diganti!", 'success'); } catch (e) { addToast(e, 'error'); } };
-    const handleChangeMasterPass = async () => { if (!settingsPassInput) return; await SetMasterPassword(settingsPassInput); addToast("Master Password Diubah", 'success'); setSettingsPassInput(''); };
+    const handleChangeMasterPass = async () => { if (!settingsPassInput) return; try { await SetMasterPassword(settingsPassInput); addToast("Master Password Diubah", 'success'); setSettingsPassInput(''); } catch (err) { addToast(String(err), 'error'); } };
     const handleChangeHiddenPass = async () => { if (!settingsPassInput) return; await SetHiddenZonePassword(settingsPassInput); addToast("Hidden Password Diubah", 'success'); setSettingsPassInput(''); };
+    const handleSetDecoyPass = async () => {
+        if (!settingsPassInput) return;
+        if (!confirm("Buat vault decoy? Decoy lama (jika ada) akan dihapus.")) return;
+        try { await SetDecoyPassword(settingsPassInput); addToast("Decoy Password Disimpan", 'success'); setSettingsPassInput(''); }
+        catch (err) { addToast(String(err), 'error'); }
+    };
+    const handleRegenerateRecoveryKey = async () => {
+        if (!confirm("Buat recovery key baru? Recovery key lama tidak berlaku lagi.")) return;
+        try { setShownRecoveryKey(await RegenerateRecoveryKey()); }
+        catch (err) { addToast(String(err), 'error'); }
+    };
 
     // --- RENDERERS ---
     // (renderSidebar, renderSeriesList, renderLibraryView, renderChapterList, renderEditModal, renderSettingsModal, renderLoginModal, renderAdminDashboard)
@@ -368,26 +489,59 @@ function App() {
         </div>
     );
 
+    // [BARU] Audit log keamanan
+    const loadAuditLog = async (onlyFailures = auditOnlyFailures) => {
+        try { setAuditLog(await GetAuditLog({ only_failures: onlyFailures, limit: 200, page: 1 })); }
+        catch (err) { addToast(String(err), 'error'); }
+    };
+
     const renderAdminDashboard = () => {
         if (!dashboardData && adminViewMode === 'stats') loadDashboard();
         if (allTags.length === 0 && adminViewMode === 'tags') loadTagsAdmin();
         return (
             <div className="content-scroll-area" style={{padding: '20px'}}>
-                <div className="library-header" style={{marginBottom: 20}}> <h2>Admin Dashboard</h2> <div style={{display:'flex', gap:10}}> <button className={`auth-button compact ${adminViewMode==='stats'?'':'secondary'}`} onClick={()=>{setAdminViewMode('stats'); loadDashboard();}}>Overview</button> <button className={`auth-button compact ${adminViewMode==='tags'?'':'secondary'}`} onClick={()=>{setAdminViewMode('tags'); loadTagsAdmin();}}>Tag Manager</button> </div> </div>
-                {adminViewMode === 'stats' && dashboardData && ( <div className="dashboard-grid"> <div className="stat-card"><h3>{dashboardData.total_books}</h3><p>Total Buku</p></div> <div className="stat-card"><h3>{dashboardData.total_series}</h3><p>Total Series</p></div> <div className="stat-card"><h3>{dashboardData.total_tags}</h3><p>Total Tags</p></div> <div className="stat-panel full-width"> <h4>Top Tags</h4> <div className="tags-bar-chart"> {dashboardData.top_tags.map(t => ( <div key={t.name} className="tag-bar-item"> <div style={{display:'flex', justifyContent:'space-between', marginBottom:5}}> <span>{t.name}</span> <span style={{color:'#a6adc8'}}>{t.count}</span> </div> <div className="progress-bg"><div className="progress-fill" style={{width: `${(t.count / dashboardData.top_tags[0].count) * 100}%`}}></div></div> </div> ))} </div> </div> <div className="stat-panel full-width"> <h4>Baru Dibaca / Ditambahkan</h4> <div className="mini-book-list"> {dashboardData.recent_books.map(b => ( <div key={b.name} className="mini-book-item" onClick={() => handleOpenBook(b)}> <img src={`/thumbnail/${encodeURIComponent(b.name)}?t=${Date.now()}`} alt="thm"/> <div> <div style={{fontWeight:'bold'}}>{b.name.replace(/_/g, ' ')}</div> <div style={{fontSize:'0.8rem', color:'#a6adc8'}}>Hal. {b.last_page + 1}</div> </div> </div> ))} </div> </div> </div> )}
+                <div className="library-header" style={{marginBottom: 20}}> <h2>Admin Dashboard</h2> <div style={{display:'flex', gap:10}}> <button className={`auth-button compact ${adminViewMode==='stats'?'':'secondary'}`} onClick={()=>{setAdminViewMode('stats'); loadDashboard();}}>Overview</button> <button className={`auth-button compact ${adminViewMode==='tags'?'':'secondary'}`} onClick={()=>{setAdminViewMode('tags'); loadTagsAdmin();}}>Tag Manager</button> <button className={`auth-button compact ${adminViewMode==='audit'?'':'secondary'}`} onClick={()=>{setAdminViewMode('audit'); loadAuditLog();}}>Audit Log</button> </div> </div>
+                {adminViewMode === 'audit' && auditLog && ( <div className="stat-panel full-width"> <div style={{display:'flex', justifyContent:'space-between', alignItems:'center', marginBottom:15}}> <h4 style={{margin:0}}>Audit Log ({auditLog.total}) {auditLog.intact ? <span style={{color:'#a6e3a1'}}>✔ utuh</span> : <span style={{color:'#f38ba8'}}>⚠ rantai rusak di entry #{auditLog.broken_at}</span>}</h4> <label style={{fontSize:'0.9rem'}}><input type="checkbox" checked={auditOnlyFailures} onChange={e => { setAuditOnlyFailures(e.target.checked); loadAuditLog(e.target.checked); }} /> Hanya yang gagal</label> </div> <div className="tag-manager-list"> {(auditLog.entries || []).map(e => ( <div key={e.id} className="tag-manager-row"> <span style={{color:'#a6adc8', minWidth:160}}>{new Date(e.time * 1000).toLocaleString()}</span> <span style={{fontWeight:'bold', flex:1}}>{e.event} <span style={{color:'#a6adc8', fontWeight:'normal'}}>{e.target} {e.detail}</span></span> <span style={{color: e.success ? '#a6e3a1' : '#f38ba8'}}>{e.success ? 'OK' : 'GAGAL'}</span> </div> ))} </div> </div> )}
+                {adminViewMode === 'stats' && dashboardData && ( <div className="dashboard-grid"> <div className="stat-card"><h3>{dashboardData.total_books}</h3><p>Total Buku</p></div> <div className="stat-card"><h3>{dashboardData.total_series}</h3><p>Total Series</p></div> <div className="stat-card"><h3>{dashboardData.total_tags}</h3><p>Total Tags</p></div> <div className="stat-panel full-width"> <h4>Top Tags</h4> <div className="tags-bar-chart"> {dashboardData.top_tags.map(t => ( <div key={t.name} className="tag-bar-item"> <div style={{display:'flex', justifyContent:'space-between', marginBottom:5}}> <span>{t.name}</span> <span style={{color:'#a6adc8'}}>{t.count}</span> </div> <div className="progress-bg"><div className="progress-fill" style={{width: `${(t.count / dashboardData.top_tags[0].count) * 100}%`}}></div></div> </div> ))} </div> </div> <div className="stat-panel full-width"> <h4>Baru Dibaca / Ditambahkan</h4> <div className="mini-book-list"> {dashboardData.recent_books.map(b => ( <div key={b.name} className="mini-book-item" onClick={() => handleOpenBook(b)}> <img src={`/thumbnail/${b.id}?t=${Date.now()}`} alt="thm"/> <div> <div style={{fontWeight:'bold'}}>{b.name.replace(/_/g, ' ')}</div> <div style={{fontSize:'0.8rem', color:'#a6adc8'}}>Hal. {b.last_page + 1}</div> </div> </div> ))} </div> </div> </div> )}
                 {adminViewMode === 'tags' && ( <div className="stat-panel full-width"> <div style={{display:'flex', justifyContent:'space-between', marginBottom:15}}> <h4>Manage All Tags ({allTags.length})</h4> <input className="auth-input compact" style={{width:200}} placeholder="Cari tag..." value={tagSearch} onChange={e=>setTagSearch(e.target.value)} /> </div> <div className="tag-manager-list"> {allTags.filter(t => t.name.toLowerCase().includes(tagSearch.toLowerCase())).map(t => ( <div key={t.name} className="tag-manager-row"> <div style={{display:'flex', alignItems:'center', gap:10}}> <TagIcon /> <span style={{fontWeight:'bold', color:'#cdd6f4'}}>{t.name}</span> <span className="tag-count-badge">{t.count} buku</span> </div> <div style={{display:'flex', gap:5}}> <button className="action-btn" onClick={()=>handleRenameTag(t.name)}><EditIcon/></button> <button className="action-btn danger" onClick={()=>handleDeleteTagMaster(t.name)}><TrashIcon/></button> </div> </div> ))} </div> </div> )}
             </div>
         );
     };
     
     // Series & Library Views (Copy paste dari sebelumnya)
-    const renderSeriesList = () => ( <div className="content-scroll-area"> <div className="library-header" style={{display:'flex', justifyContent:'space-between', alignItems:'center', marginBottom:15}}> <div style={{color:'#a6adc8', fontSize:'0.9rem'}}>{seriesList.length} Series</div> {isAdmin && <button className="auth-button compact" onClick={() => setShowCreateSeries(true)}>+ Buat Series</button>} </div> <div className="book-grid"> {seriesList.map(s => ( <div key={s.id} className="book-card" onClick={() => handleOpenSeries(s)}> <div className="book-cover"> <div style={{position:'absolute', top:-5, right:-5, width:'100%', height:'100%', background:'#313244', borderRadius:8, zIndex:-1}}></div> <div style={{position:'absolute', top:-10, right:-10, width:'100%', height:'100%', background:'#1e1e2e', borderRadius:8, zIndex:-2}}></div> {s.cover_book ? ( <img src={`/thumbnail/${encodeURIComponent(s.cover_book)}?t=${Date.now()}`} alt="cover" loading="lazy" /> ) : ( <div className="book-cover-placeholder"><SeriesIcon style={{width:40,height:40}}/></div> )} <div className="book-info-overlay"><div className="book-title">{s.title}</div></div> <div className="indicator" style={{top: 'auto', bottom: 10, right: 10, background: '#89b4fa', color: '#1e1e2e'}}>{s.count} Books</div> </div> {isAdmin && ( <div className="book-actions"> <button className="action-btn danger" onClick={(e) => handleDeleteSeries(e, s.title)}><TrashIcon/></button> </div> )} </div> ))} </div> {showCreateSeries && ( <div className="modal-overlay"> <div className="login-box" style={{width:400}}> <h3 style={{marginTop:0}}>Buat Series Baru</h3> <input className="auth-input" placeholder="Nama Series" value={newSeriesName} onChange={e => setNewSeriesName(e.target.value)} autoFocus /> <div style={{display:'flex', gap:10, marginTop:15}}> <button className="auth-button" onClick={handleCreateSeries}>Buat</button> <button className="auth-button secondary" onClick={() => setShowCreateSeries(false)}>Batal</button> </div> </div> </div> )} </div> );
-    const renderLibraryView = () => ( <div className="content-scroll-area"> <div className="library-header" style={{display:'flex', justifyContent:'space-between', alignItems:'center', marginBottom:15}}> <div style={{color:'#a6adc8', fontSize:'0.9rem'}}> {activeSeries ? `Series: ${activeSeries.title} (${books.length})` : `${books.length} Buku (Loaded)`} </div> <select className="auth-input compact" style={{width:'auto', minWidth:'200px', cursor:'pointer'}} value={sortBy} onChange={(e) => setSortBy(e.target.value)}> <option value="name_asc">Nama (A-Z)</option> <option value="name_desc">Nama (Z-A)</option> <option value="date_desc">Terakhir Dibaca</option> <option value="date_asc">Terlama Dibaca</option> </select> </div> <div className="book-grid"> {books.map(b => ( <div key={b.name} className="book-card" style={{opacity: b.is_hidden ? 0.7 : 1, border: b.is_hidden ? '1px dashed #f38ba8' : 'none'}}> <div className="book-cover" onClick={() => handleOpenBook(b)}> {b.mask_cover && !hiddenZoneActive ? ( <div className="book-cover-placeholder" style={{flexDirection:'column'}}><EyeOffIcon style={{width:40,height:40}}/><span style={{fontSize:12, marginTop:10}}>Hidden</span></div> ) : ( <img src={`/thumbnail/${encodeURIComponent(b.name)}?t=${imageCacheBuster}`} alt="cover" loading="lazy" onError={(e) => {e.target.style.display='none';}} /> )} <div className="book-info-overlay"><div className="book-title">{b.name.replace(/_/g, ' ')}</div></div> <div style={{position:'absolute', top:5, left:5, display:'flex', gap:5}}> {b.is_locked && <div className="indicator locked"><LockIcon style={{width:14, height:14}} /></div>} {b.is_hidden && <div className="indicator hidden"><EyeOffIcon style={{width:14, height:14}} /></div>} </div> {b.series_name && !activeSeries && ( <div className="indicator" style={{top: 5, right: 5, background: '#cba6f7', color: '#1e1e2e', fontSize:'0.7rem', maxWidth:100, overflow:'hidden', textOverflow:'ellipsis', whiteSpace:'nowrap'}}> {b.series_name} </div> )} </div> {isAdmin && ( <div className="book-actions"> <button className="action-btn" onClick={(e)=>handleUpdate(e, b.name)}><SyncIcon/></button> <button className="action-btn" onClick={(e)=>openEditModal(e, b)}><EditIcon/></button> <button className="action-btn danger" onClick={(e)=>handleDelete(e, b.name)}><TrashIcon/></button> </div> )} </div> ))} {hasMore && <div ref={observerTarget} className="loading-sentinel" style={{gridColumn:'1/-1', textAlign:'center', padding:20, color:'#6c7086'}}>Loading...</div>} </div> {isAdmin && !activeSeries && <button className="fab" onClick={handleAddBook}>+</button>} </div> );
-    const renderChapterList = () => ( <div className="content-scroll-area"> <div className="book-hero"> <div className="hero-bg" style={{backgroundImage: `url(/thumbnail/${encodeURIComponent(currentBookObj?.name)}?t=${Date.now()})`}}></div> <div className="hero-content"> <div className="hero-cover"> <img src={`/thumbnail/${encodeURIComponent(currentBookObj?.name)}?t=${Date.now()}`} alt="Cover" /> </div> <div className="hero-info"> <h1>{currentBookObj?.name.replace(/_/g, ' ')}</h1> <p>{currentBookObj?.description || "Tidak ada deskripsi."}</p> </div> </div> </div> <div className="chapter-list-container"> <h3 style={{color:'#a6adc8'}}>Chapters ({chapters.length})</h3> <div className="chapter-list"> {chapters.map(chapter => ( <div key={chapter} className="chapter-item" onClick={() => handleOpenChapter(currentBookObj.name, chapter)}> <FolderIcon /> <div className="chapter-name">{chapter.replace(/_/g, ' ')}</div> <div className="chapter-arrow">→</div> </div> ))} </div> </div> </div> );
-    const renderGalleryView = () => ( <Reader images={imageFilenames} bookName={currentBookObj?.name} chapterName={currentChapter} chapters={chapters} onChapterChange={(newChapter) => handleOpenChapter(currentBookObj.name, newChapter)} imageCacheBuster={imageCacheBuster} initialPage={currentBookObj?.last_page || 0} onBack={handleBack} onSetCover={handleReaderSetCover} isAdmin={isAdmin} /> );
+    const renderSeriesList = () => ( <div className="content-scroll-area"> <div className="library-header" style={{display:'flex', justifyContent:'space-between', alignItems:'center', marginBottom:15}}> <div style={{color:'#a6adc8', fontSize:'0.9rem'}}>{seriesList.length} Series</div> {isAdmin && <button className="auth-button compact" onClick={() => setShowCreateSeries(true)}>+ Buat Series</button>} </div> <div className="book-grid"> {seriesList.map(s => ( <div key={s.id} className="book-card" onClick={() => handleOpenSeries(s)}> <div className="book-cover"> <div style={{position:'absolute', top:-5, right:-5, width:'100%', height:'100%', background:'#313244', borderRadius:8, zIndex:-1}}></div> <div style={{position:'absolute', top:-10, right:-10, width:'100%', height:'100%', background:'#1e1e2e', borderRadius:8, zIndex:-2}}></div> {s.cover_id ? ( <img src={`/thumbnail/${s.cover_id}?t=${Date.now()}`} alt="cover" loading="lazy" /> ) : ( <div className="book-cover-placeholder"><SeriesIcon style={{width:40,height:40}}/></div> )} <div className="book-info-overlay"><div className="book-title">{s.title}</div></div> <div className="indicator" style={{top: 'auto', bottom: 10, right: 10, background: '#89b4fa', color: '#1e1e2e'}}>{s.count} Books</div> </div> {isAdmin && ( <div className="book-actions"> <button className="action-btn danger" onClick={(e) => handleDeleteSeries(e, s.title)}><TrashIcon/></button> </div> )} </div> ))} </div> {showCreateSeries && ( <div className="modal-overlay"> <div className="login-box" style={{width:400}}> <h3 style={{marginTop:0}}>Buat Series Baru</h3> <input className="auth-input" placeholder="Nama Series" value={newSeriesName} onChange={e => setNewSeriesName(e.target.value)} autoFocus /> <div style={{display:'flex', gap:10, marginTop:15}}> <button className="auth-button" onClick={handleCreateSeries}>Buat</button> <button className="auth-button secondary" onClick={() => setShowCreateSeries(false)}>Batal</button> </div> </div> </div> )} </div> );
+    const renderLibraryView = () => ( <div className="content-scroll-area"> <div className="library-header" style={{display:'flex', justifyContent:'space-between', alignItems:'center', marginBottom:15}}> <div style={{color:'#a6adc8', fontSize:'0.9rem'}}> {activeSeries ? `Series: ${activeSeries.title} (${books.length})` : `${books.length} Buku (Loaded)`} </div> <select className="auth-input compact" style={{width:'auto', minWidth:'200px', cursor:'pointer'}} value={sortBy} onChange={(e) => setSortBy(e.target.value)}> <option value="name_asc">Nama (A-Z)</option> <option value="name_desc">Nama (Z-A)</option> <option value="date_desc">Terakhir Dibaca</option> <option value="date_asc">Terlama Dibaca</option> </select> </div> <div className="book-grid"> {books.map(b => ( <div key={b.name} className="book-card" style={{opacity: b.is_hidden ? 0.7 : 1, border: b.is_hidden ? '1px dashed #f38ba8' : 'none'}}> <div className="book-cover" onClick={() => handleOpenBook(b)}> {b.mask_cover && !hiddenZoneActive ? ( <div className="book-cover-placeholder" style={{flexDirection:'column'}}><EyeOffIcon style={{width:40,height:40}}/><span style={{fontSize:12, marginTop:10}}>Hidden</span></div> ) : ( <img src={`/thumbnail/${b.id}?t=${imageCacheBuster}`} alt="cover" loading="lazy" onError={(e) => {e.target.style.display='none';}} /> )} <div className="book-info-overlay"><div className="book-title">{b.name.replace(/_/g, ' ')}</div>{b.author && <div style={{fontSize:'0.75rem', color:'#a6adc8'}}>{b.author}</div>}</div> <div style={{position:'absolute', top:5, left:5, display:'flex', gap:5}}> {b.is_locked && <div className="indicator locked"><LockIcon style={{width:14, height:14}} /></div>} {b.is_hidden && <div className="indicator hidden"><EyeOffIcon style={{width:14, height:14}} /></div>} </div> {b.series_name && !activeSeries && ( <div className="indicator" style={{top: 5, right: 5, background: '#cba6f7', color: '#1e1e2e', fontSize:'0.7rem', maxWidth:100, overflow:'hidden', textOverflow:'ellipsis', whiteSpace:'nowrap'}}> {b.series_name} </div> )} </div> {isAdmin && ( <div className="book-actions"> <button className="action-btn" onClick={(e)=>handleUpdate(e, b.name)}><SyncIcon/></button> <button className="action-btn" onClick={(e)=>openEditModal(e, b)}><EditIcon/></button> <button className="action-btn danger" onClick={(e)=>handleDelete(e, b.name)}><TrashIcon/></button> </div> )} </div> ))} {hasMore && <div ref={observerTarget} className="loading-sentinel" style={{gridColumn:'1/-1', textAlign:'center', padding:20, color:'#6c7086'}}>Loading...</div>} </div> {isAdmin && !activeSeries && <button className="fab" onClick={handleAddBook}>+</button>} </div> );
+    const renderChapterList = () => ( <div className="content-scroll-area"> <div className="book-hero"> <div className="hero-bg" style={{backgroundImage: `url(/thumbnail/${currentBookObj?.id}?t=${Date.now()})`}}></div> <div className="hero-content"> <div className="hero-cover"> <img src={`/thumbnail/${currentBookObj?.id}?t=${Date.now()}`} alt="Cover" /> </div> <div className="hero-info"> <h1>{currentBookObj?.name.replace(/_/g, ' ')}</h1> <p>{currentBookObj?.description || "Tidak ada deskripsi."}</p> </div> </div> </div> <div className="chapter-list-container"> <h3 style={{color:'#a6adc8'}}>Chapters ({chapters.length})</h3> <div className="chapter-list"> {chapters.map(chapter => ( <div key={chapter} className="chapter-item" onClick={() => handleOpenChapter(currentBookObj.name, chapter)}> <FolderIcon /> <div className="chapter-name">{chapter.replace(/_/g, ' ')}</div> <div className="chapter-arrow">→</div> </div> ))} </div> </div> </div> );
+    const renderGalleryView = () => ( <Reader images={imageFilenames} bookId={currentBookObj?.id} bookName={currentBookObj?.name} chapterName={currentChapter} chapters={chapters} onChapterChange={(newChapter) => handleOpenChapter(currentBookObj.name, newChapter)} imageCacheBuster={imageCacheBuster} initialPage={currentBookObj?.last_page || 0} onBack={handleBack} onSetCover={handleReaderSetCover} isAdmin={isAdmin} rtl={currentBookObj?.reading_direction === 'rtl'} /> );
     const renderEditModal = () => { if(!editingBook) return null; return ( <div className="modal-overlay"> <div className="login-box" onClick={e => e.stopPropagation()} style={{textAlign:'left', width: 500}}> <h2 style={{marginTop:0, color:'#89b4fa'}}>Edit Info</h2> <div style={{marginBottom:15}}> <label className="input-label">Series Group</label> <select className="auth-input compact" value={editSeriesInput} onChange={e => setEditSeriesInput(e.target.value)}> <option value="">-- Tidak ada Series --</option> {seriesList.map(s => <option key={s.id} value={s.title}>{s.title}</option>)} <option value="NO_SERIES" style={{color:'#f38ba8'}}>Keluarkan dari Series</option> </select> </div> <div style={{display:'grid', gridTemplateColumns:'1fr 1fr', gap:15}}> <div><label className="input-label">Judul</label><input className="auth-input compact" value={editNameInput} onChange={e => setEditNameInput(e.target.value)} /></div> <div><label className="input-label">Tags</label><input className="auth-input compact" value={editTagsInput} onChange={e => setEditTagsInput(e.target.value)} /></div> </div> <label className="input-label">Deskripsi</label> <textarea className="auth-input compact" style={{height:80, resize:'vertical'}} value={editDescInput} onChange={e => setEditDescInput(e.target.value)} /> {hiddenZoneActive && ( <div className="security-section"> <label className="input-label" style={{color:'#f38ba8'}}>Keamanan</label> <input className="auth-input compact" type="password" value={editLockPass} onChange={e => setEditLockPass(e.target.value)} placeholder="Set Password Baru"/> <div style={{display:'grid', gridTemplateColumns:'1fr 1fr', gap:10, marginTop:10}}> <div className="checkbox-row"><input type="checkbox" checked={editIsHidden} onChange={e => setEditIsHidden(e.target.checked)} /><label>Hidden Book</label></div> <div className="checkbox-row"><input type="checkbox" checked={editMaskCover} onChange={e => setEditMaskCover(e.target.checked)} /><label>Mask Cover</label></div> </div> {editingBook.is_locked && <button onClick={handleUnlockAction} className="unlock-btn">Hapus Password</button>} </div> )} <div style={{display:'flex', gap:10, marginTop:20}}> <button className="auth-button" onClick={saveMetadata}>Simpan</button> <button className="auth-button secondary" onClick={() => setEditingBook(null)}>Batal</button> </div> </div> </div> ); };
-    const renderSettingsModal = () => { if (!showSettings) return null; return ( <div className="modal-overlay"> <div className="login-box" onClick={e => e.stopPropagation()} style={{textAlign:'left'}}> <h2 style={{marginTop:0, color:'#89b4fa'}}>Settings</h2> <input className="auth-input" type="password" value={settingsPassInput} onChange={e => setSettingsPassInput(e.target.value)} placeholder="Password Baru" /> <div style={{display:'flex', flexDirection:'column', gap:10, marginTop:10}}> <button className="auth-button" onClick={handleChangeMasterPass}>Ubah Master Password</button> <button className="auth-button" style={{background:'#f38ba8', color:'#1e1e2e'}} onClick={handleChangeHiddenPass}>Ubah Hidden Zone Password</button> </div> <button className="auth-button secondary" style={{marginTop:20}} onClick={() => {setShowSettings(false); setSettingsPassInput('');}}>Tutup</button> </div> </div> ); };
-    const renderLoginModal = () => { if (!showLoginModal) return null; return ( <div className="modal-overlay" onClick={() => setShowLoginModal(false)}> <div className="login-box" onClick={e => e.stopPropagation()}> <h2 style={{marginTop:0}}>Admin Access</h2> <form onSubmit={handleAdminLogin}> <input type="password" className="auth-input" value={passwordInput} onChange={e=>setPasswordInput(e.target.value)} autoFocus placeholder="Passphrase"/> <button className="auth-button" style={{marginTop:10}}>Unlock</button> </form> </div> </div> ); };
+    const handleSaveSessionSettings = async () => {
+        try {
+            await SetSessionSettings({ ...sessionSettings, idle_minutes: parseInt(sessionSettings.idle_minutes, 10) || 0 });
+            addToast("Pengaturan Auto-Lock Disimpan", 'success');
+        } catch (err) { addToast(String(err), 'error'); }
+    };
+    const handleSaveImageSettings = async () => {
+        const num = (v) => parseInt(v, 10) || 0;
+        const profile = (p) => ({ ...p, max_width: num(p.max_width), max_height: num(p.max_height), quality: num(p.quality) });
+        try {
+            const s = { ...imageSettings, compressed: profile(imageSettings.compressed), lossless: profile(imageSettings.lossless), thumb_width: num(imageSettings.thumb_width), thumb_quality: num(imageSettings.thumb_quality) };
+            await SetImageSettings(s);
+            setImageSettingsState(s); setImageCacheBuster(Date.now());
+            addToast("Pengaturan Kompresi Disimpan", 'success');
+        } catch (err) { addToast(String(err), 'error'); }
+    };
+    const setProfileSetting = (profile, field, value) => setImageSettingsState({ ...imageSettings, [profile]: { ...imageSettings[profile], [field]: value } });
+    const resampleFilterOptions = ['lanczos', 'catmullrom', 'linear', 'box', 'nearest'].map(f => <option key={f} value={f}>{f}</option>);
+    const renderImageSettings = () => { if (!imageSettings) return null; return ( <> <h4 style={{color:'#a6adc8', marginBottom:5}}>Kompresi Gambar</h4> <label className="input-label">Compressed: lebar / tinggi maks (px, 0 = tanpa batas), kualitas JPEG, filter</label> <div style={{display:'grid', gridTemplateColumns:'1fr 1fr 1fr 1fr', gap:8}}> <input className="auth-input compact" type="number" min="0" value={imageSettings.compressed.max_width} onChange={e => setProfileSetting('compressed', 'max_width', e.target.value)} /> <input className="auth-input compact" type="number" min="0" value={imageSettings.compressed.max_height} onChange={e => setProfileSetting('compressed', 'max_height', e.target.value)} /> <input className="auth-input compact" type="number" min="1" max="100" value={imageSettings.compressed.quality} onChange={e => setProfileSetting('compressed', 'quality', e.target.value)} /> <select className="auth-input compact" value={imageSettings.compressed.filter} onChange={e => setProfileSetting('compressed', 'filter', e.target.value)}>{resampleFilterOptions}</select> </div> <label className="input-label">Lossless: lebar / tinggi maks (px, 0 = resolusi asli), filter</label> <div style={{display:'grid', gridTemplateColumns:'1fr 1fr 1fr', gap:8}}> <input className="auth-input compact" type="number" min="0" value={imageSettings.lossless.max_width} onChange={e => setProfileSetting('lossless', 'max_width', e.target.value)} /> <input className="auth-input compact" type="number" min="0" value={imageSettings.lossless.max_height} onChange={e => setProfileSetting('lossless', 'max_height', e.target.value)} /> <select className="auth-input compact" value={imageSettings.lossless.filter} onChange={e => setProfileSetting('lossless', 'filter', e.target.value)}>{resampleFilterOptions}</select> </div> <label className="input-label">Thumbnail: lebar (px), format, kualitas JPEG, filter</label> <div style={{display:'grid', gridTemplateColumns:'1fr 1fr 1fr 1fr', gap:8}}> <input className="auth-input compact" type="number" min="64" max="1024" value={imageSettings.thumb_width} onChange={e => setImageSettingsState({...imageSettings, thumb_width: e.target.value})} /> <select className="auth-input compact" value={imageSettings.thumb_format} onChange={e => setImageSettingsState({...imageSettings, thumb_format: e.target.value})}> <option value="jpeg">JPEG</option> <option value="png">PNG</option> </select> <input className="auth-input compact" type="number" min="1" max="100" disabled={imageSettings.thumb_format !== 'jpeg'} value={imageSettings.thumb_quality} onChange={e => setImageSettingsState({...imageSettings, thumb_quality: e.target.value})} /> <select className="auth-input compact" value={imageSettings.thumb_filter} onChange={e => setImageSettingsState({...imageSettings, thumb_filter: e.target.value})}>{resampleFilterOptions}</select> </div> <button className="auth-button" style={{marginTop:10}} onClick={handleSaveImageSettings}>Simpan Kompresi</button> </> ); };
+    const handleClearThumbnails = async () => {
+        try { await ClearThumbnailCache(); setImageCacheBuster(Date.now()); addToast("Cache Thumbnail Dihapus", 'success'); }
+        catch (err) { addToast(String(err), 'error'); }
+    };
+    const renderSettingsModal = () => { if (!showSettings) return null; return ( <div className="modal-overlay"> <div className="login-box" onClick={e => e.stopPropagation()} style={{textAlign:'left', maxHeight:'90vh', overflowY:'auto'}}> <h2 style={{marginTop:0, color:'#89b4fa'}}>Settings</h2> <input className="auth-input" type="password" value={settingsPassInput} onChange={e => setSettingsPassInput(e.target.value)} placeholder="Password Baru" /> <div style={{display:'flex', flexDirection:'column', gap:10, marginTop:10}}> <button className="auth-button" onClick={handleChangeMasterPass}>Ubah Master Password</button> <button className="auth-button" style={{background:'#f38ba8', color:'#1e1e2e'}} onClick={handleChangeHiddenPass}>Ubah Hidden Zone Password</button> <button className="auth-button secondary" onClick={handleSetDecoyPass}>Set Decoy Password</button> <button className="auth-button secondary" onClick={handleRegenerateRecoveryKey}>Buat Recovery Key Baru</button> </div> <h4 style={{color:'#a6adc8', marginBottom:5}}>Auto-Lock</h4> <label style={{fontSize:'0.9rem'}}>Kunci setelah idle (menit, 0 = mati)</label> <input className="auth-input" type="number" min="0" value={sessionSettings.idle_minutes} onChange={e => setSessionSettingsState({...sessionSettings, idle_minutes: e.target.value})} /> <label style={{display:'flex', alignItems:'center', gap:8, marginTop:8, fontSize:'0.9rem'}}> <input type="checkbox" checked={sessionSettings.lock_on_blur} onChange={e => setSessionSettingsState({...sessionSettings, lock_on_blur: e.target.checked})} /> Kunci saat jendela di-minimize / tidak fokus </label> <button className="auth-button" style={{marginTop:10}} onClick={handleSaveSessionSettings}>Simpan Auto-Lock</button> {renderImageSettings()} <button className="auth-button secondary" style={{marginTop:10}} onClick={handleClearThumbnails}>Hapus Cache Thumbnail</button> <h4 style={{color:'#a6adc8', marginBottom:5}}>Panic (Ctrl+Shift+X)</h4> <label style={{display:'flex', alignItems:'center', gap:8, fontSize:'0.9rem', color:'#f38ba8'}}> <input type="checkbox" checked={panicDestroyKey} onChange={e => setPanicDestroyKey(e.target.checked)} /> Hancurkan kunci vault saat panic (isi vault hilang permanen) </label> <button className="auth-button secondary" style={{marginTop:20}} onClick={() => {setShowSettings(false); setSettingsPassInput('');}}>Tutup</button> </div> </div> ); };
+    const renderLoginModal = () => { if (!showLoginModal) return null; return ( <div className="modal-overlay" onClick={() => setShowLoginModal(false)}> <div className="login-box" onClick={e => e.stopPropagation()}> <h2 style={{marginTop:0}}>{recoveryMode ? 'Reset Password' : 'Admin Access'}</h2> <form onSubmit={handleAdminLogin}> {recoveryMode && <textarea className="auth-input" rows={4} value={recoveryInput} onChange={e=>setRecoveryInput(e.target.value)} autoFocus placeholder="Recovery key (24 kata)"/>} <input type="password" className="auth-input" value={passwordInput} onChange={e=>setPasswordInput(e.target.value)} autoFocus={!recoveryMode} placeholder={recoveryMode ? "Password Baru" : "Passphrase"}/> <button className="auth-button" style={{marginTop:10}}>{recoveryMode ? 'Reset & Unlock' : 'Unlock'}</button> </form> {hasPasswordSetup && <button className="auth-button secondary" style={{marginTop:10}} onClick={() => {setRecoveryMode(!recoveryMode); setRecoveryInput('');}}>{recoveryMode ? 'Kembali' : 'Lupa Password?'}</button>} </div> </div> ); };
+    const formatEta = (s) => s < 0 ? '...' : s >= 60 ? `${Math.floor(s / 60)}m ${s % 60}s` : `${s}s`;
+    const renderImportJobs = () => { const jobs = Object.values(importJobs); if (jobs.length === 0) return null; return ( <div className="import-jobs"> {jobs.map(j => ( <div key={j.job_id} className="import-job"> <div style={{display:'flex', justifyContent:'space-between', gap:10}}> <span className="import-job-title">{j.book ? `${j.book_count > 1 ? `(${j.book_index}/${j.book_count}) ` : ''}${j.book}` : 'Menunggu...'}</span> <button className="action-btn danger" title="Batalkan" onClick={() => CancelImport(j.job_id).catch(() => {})}>✕</button> </div> <div className="progress-bg" style={{margin:'6px 0'}}><div className="progress-fill" style={{width: `${j.total ? (j.done / j.total) * 100 : 0}%`}}></div></div> <div style={{fontSize:'0.75rem', color:'#a6adc8', display:'flex', justifyContent:'space-between'}}> <span>{j.done}/{j.total}{j.failed ? ` (${j.failed} gagal)` : ''} · {(j.bytes / 1048576).toFixed(1)} MB</span> <span>ETA {formatEta(j.eta_seconds)}</span> </div> {j.current && <div className="import-job-current">{j.current}</div>} </div> ))} </div> ); };
+    const renderRecoveryKeyModal = () => { if (!shownRecoveryKey) return null; return ( <div className="modal-overlay"> <div className="login-box" onClick={e => e.stopPropagation()} style={{textAlign:'left'}}> <h2 style={{marginTop:0, color:'#f9e2af'}}>Recovery Key</h2> <p style={{fontSize:'0.9rem'}}>Catat 24 kata ini dan simpan di tempat aman (offline). Hanya ini cara membuka vault jika master password lupa. Recovery key tidak akan ditampilkan lagi.</p> <ol style={{columns:3, fontFamily:'monospace', fontSize:'0.95rem'}}>{shownRecoveryKey.split(' ').map((w, i) => <li key={i}>{w}</li>)}</ol> <button className="auth-button" style={{marginTop:10}} onClick={() => setShownRecoveryKey('')}>Sudah Saya Catat</button> </div> </div> ); };
 
     if (hasPasswordSetup === null) return <div className="loading-overlay">Loading...</div>;
     return ( 
@@ -413,7 +567,8 @@ function App() {
                 {view === 'gallery' && renderGalleryView()} 
                 {view === 'admin' && renderAdminDashboard()}
             </div> 
-            {renderEditModal()} {renderSettingsModal()} {renderLoginModal()} 
+            {renderImportJobs()}
+            {renderEditModal()} {renderSettingsModal()} {renderLoginModal()} {renderRecoveryKeyModal()} 
         </div> 
     );
 }
@@ -40,10 +40,11 @@ const LazyImage = ({ src, alt, index, onInView }) => {
 
 // --- MAIN READER COMPONENT ---
 const Reader = ({ 
-    images, bookName, chapterName, 
+    images, bookId, bookName, chapterName, 
     chapters = [], 
     onChapterChange, 
-    imageCacheBuster, initialPage, onBack, onSetCover, isAdmin 
+    imageCacheBuster, initialPage, onBack, onSetCover, isAdmin,
+    rtl = false // [BARU] Arah baca kanan-ke-kiri (manga)
 }) => {
     // [UPDATE] Load preference dari localStorage, default 'webtoon'
     const [readMode, setReadMode] = useState(localStorage.getItem('gv_readMode') || 'webtoon'); 
@@ -132,8 +133,10 @@ const Reader = ({
             
             // Mode Single Page Navigation
             if (readMode === 'single') {
-                if (e.key === 'ArrowRight') changePage(1);
-                if (e.key === 'ArrowLeft') changePage(-1);
+                // Manga (RTL): panah kiri = halaman berikutnya
+                const step = rtl ? -1 : 1;
+                if (e.key === 'ArrowRight') changePage(step);
+                if (e.key === 'ArrowLeft') changePage(-step);
             }
 
             // Shortcut Ganti Chapter (Shift + Panah)
@@ -142,11 +145,11 @@ const Reader = ({
         };
         window.addEventListener('keydown', handleKey);
         return () => window.removeEventListener('keydown', handleKey);
-    }, [currentIndex, readMode, chapterName, chapters]); // Dependency penting agar state terbaca update
+    }, [currentIndex, readMode, chapterName, chapters, rtl]); // Dependency penting agar state terbaca update
 
     // Helper URL Gambar
     const getImageUrl = (filename) => {
-        const safeBook = encodeURIComponent(bookName);
+        const safeBook = encodeURIComponent(bookId); // URL pakai ID buku, bukan judul
         const safeFile = encodeURIComponent(filename);
         
         let url = `/img/${safeBook}/${safeFile}`;
@@ -2,37 +2,103 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
-export function BatchImportBooks(arg1:string):Promise<Array<string>>;
+export function AddBookToSeries(arg1:string,arg2:string):Promise<void>;
+
+export function BatchImportBooks(arg1:string,arg2:main.ImportOptions):Promise<Array<string>>;
+
+export function CancelImport(arg1:string):Promise<void>;
 
 export function CheckAccess(arg1:string):Promise<boolean>;
 
-export function CreateBook(arg1:string,arg2:string,arg3:boolean):Promise<string>;
+export function ClearThumbnailCache():Promise<void>;
+
+export function CreateBook(arg1:string,arg2:string,arg3:boolean,arg4:main.ImportOptions):Promise<string>;
+
+export function CreateSeries(arg1:string,arg2:string):Promise<string>;
 
 export function DeleteBook(arg1:string):Promise<void>;
 
+export function DeleteSeries(arg1:string):Promise<void>;
+
+export function DeleteTagMaster(arg1:string):Promise<string>;
+
+export function GetAllSeries():Promise<Array<main.SeriesFrontend>>;
+
+export function GetAllTagsAdmin():Promise<Array<main.TagWithCount>>;
+
+export function GetAuditLog(arg1:main.AuditFilter):Promise<main.AuditLogResult>;
+
 export function GetBooks(arg1:main.SearchQuery):Promise<Array<main.BookFrontend>>;
 
 export function GetChapters(arg1:string):Promise<Array<string>>;
 
+export function GetDashboardStats():Promise<main.DashboardStats>;
+
+export function GetImageSettings():Promise<main.ImageSettings>;
+
 export function GetImagesInChapter(arg1:string,arg2:string):Promise<Array<string>>;
 
+export function GetObfuscateNames():Promise<boolean>;
+
+export function GetSessionSettings():Promise<main.SessionSettings>;
+
+export function GetUnlockRetryAfter():Promise<number>;
+
+export function HasDecoyPassword():Promise<boolean>;
+
 export function HasHiddenZonePassword():Promise<boolean>;
 
 export function HasPassword():Promise<boolean>;
 
+export function HasRecoveryKey():Promise<boolean>;
+
 export function IsHiddenZoneActive():Promise<boolean>;
 
+export function IsRotatingVaultKey():Promise<boolean>;
+
+export function IsVaultUnlocked():Promise<boolean>;
+
 export function LockBook(arg1:string,arg2:string):Promise<void>;
 
 export function LockHiddenZone():Promise<void>;
 
+export function LockVault():Promise<void>;
+
+export function Panic(arg1:boolean):Promise<void>;
+
+export function RecoverWithKey(arg1:string,arg2:string):Promise<void>;
+
+export function RegenerateRecoveryKey():Promise<string>;
+
+export function RemoveBookFromSeries(arg1:string):Promise<void>;
+
+export function RenameTag(arg1:string,arg2:string):Promise<string>;
+
+export function ReportActivity():Promise<void>;
+
+export function RotateVaultKey(arg1:string,arg2:string):Promise<void>;
+
+export function SelectArchive():Promise<string>;
+
 export function SelectFolder():Promise<string>;
 
 export function SetBookCover(arg1:string,arg2:string):Promise<void>;
 
+export function SetDecoyPassword(arg1:string):Promise<void>;
+
 export function SetHiddenZonePassword(arg1:string):Promise<boolean>;
 
-export function SetMasterPassword(arg1:string):Promise<boolean>;
+export function SetImageSettings(arg1:main.ImageSettings):Promise<void>;
+
+export function SetMasterPassword(arg1:string):Promise<string>;
+
+export function SetObfuscateNames(arg1:boolean):Promise<void>;
+
+export function SetSessionSettings(arg1:main.SessionSettings):Promise<void>;
+
+export function StartBatchImport(arg1:string,arg2:main.ImportOptions):Promise<string>;
+
+export function StartImport(arg1:string,arg2:string,arg3:boolean,arg4:main.ImportOptions):Promise<string>;
 
 export function ToggleBookFavorite(arg1:string):Promise<boolean>;
 
@@ -47,3 +113,5 @@ export function UpdateBookProgress(arg1:string,arg2:number):Promise<void>;
 export function VerifyBookPassword(arg1:string,arg2:string):Promise<boolean>;
 
 export function VerifyPassword(arg1:string):Promise<boolean>;
+
+export function WindowHidden():Promise<void>;
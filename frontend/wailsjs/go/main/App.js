@@ -2,22 +2,58 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function BatchImportBooks(arg1) {
-  return window['go']['main']['App']['BatchImportBooks'](arg1);
+export function AddBookToSeries(arg1, arg2) {
+  return window['go']['main']['App']['AddBookToSeries'](arg1, arg2);
+}
+
+export function BatchImportBooks(arg1, arg2) {
+  return window['go']['main']['App']['BatchImportBooks'](arg1, arg2);
+}
+
+export function CancelImport(arg1) {
+  return window['go']['main']['App']['CancelImport'](arg1);
 }
 
 export function CheckAccess(arg1) {
   return window['go']['main']['App']['CheckAccess'](arg1);
 }
 
-export function CreateBook(arg1, arg2, arg3) {
-  return window['go']['main']['App']['CreateBook'](arg1, arg2, arg3);
+export function ClearThumbnailCache() {
+  return window['go']['main']['App']['ClearThumbnailCache']();
+}
+
+export function CreateBook(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CreateBook'](arg1, arg2, arg3, arg4);
+}
+
+export function CreateSeries(arg1, arg2) {
+  return window['go']['main']['App']['CreateSeries'](arg1, arg2);
 }
 
 export function DeleteBook(arg1) {
   return window['go']['main']['App']['DeleteBook'](arg1);
 }
 
+export function DeleteSeries(arg1) {
+  return window['go']['main']['App']['DeleteSeries'](arg1);
+}
+
+export function DeleteTagMaster(arg1) {
+  return window['go']['main']['App']['DeleteTagMaster'](arg1);
+}
+
+export function GetAllSeries() {
+  return window['go']['main']['App']['GetAllSeries']();
+}
+
+export function GetAllTagsAdmin() {
+  return window['go']['main']['App']['GetAllTagsAdmin']();
+}
+
+export function GetAuditLog(arg1) {
+  return window['go']['main']['App']['GetAuditLog'](arg1);
+}
+
 export function GetBooks(arg1) {
   return window['go']['main']['App']['GetBooks'](arg1);
 }
@@ -26,10 +62,34 @@ export function GetChapters(arg1) {
   return window['go']['main']['App']['GetChapters'](arg1);
 }
 
+export function GetDashboardStats() {
+  return window['go']['main']['App']['GetDashboardStats']();
+}
+
+export function GetImageSettings() {
+  return window['go']['main']['App']['GetImageSettings']();
+}
+
 export function GetImagesInChapter(arg1, arg2) {
   return window['go']['main']['App']['GetImagesInChapter'](arg1, arg2);
 }
 
+export function GetObfuscateNames() {
+  return window['go']['main']['App']['GetObfuscateNames']();
+}
+
+export function GetSessionSettings() {
+  return window['go']['main']['App']['GetSessionSettings']();
+}
+
+export function GetUnlockRetryAfter() {
+  return window['go']['main']['App']['GetUnlockRetryAfter']();
+}
+
+export function HasDecoyPassword() {
+  return window['go']['main']['App']['HasDecoyPassword']();
+}
+
 export function HasHiddenZonePassword() {
   return window['go']['main']['App']['HasHiddenZonePassword']();
 }
@@ -38,10 +98,22 @@ export function HasPassword() {
   return window['go']['main']['App']['HasPassword']();
 }
 
+export function HasRecoveryKey() {
+  return window['go']['main']['App']['HasRecoveryKey']();
+}
+
 export function IsHiddenZoneActive() {
   return window['go']['main']['App']['IsHiddenZoneActive']();
 }
 
+export function IsRotatingVaultKey() {
+  return window['go']['main']['App']['IsRotatingVaultKey']();
+}
+
+export function IsVaultUnlocked() {
+  return window['go']['main']['App']['IsVaultUnlocked']();
+}
+
 export function LockBook(arg1, arg2) {
   return window['go']['main']['App']['LockBook'](arg1, arg2);
 }
@@ -50,6 +122,42 @@ export function LockHiddenZone() {
   return window['go']['main']['App']['LockHiddenZone']();
 }
 
+export function LockVault() {
+  return window['go']['main']['App']['LockVault']();
+}
+
+export function Panic(arg1) {
+  return window['go']['main']['App']['Panic'](arg1);
+}
+
+export function RecoverWithKey(arg1, arg2) {
+  return window['go']['main']['App']['RecoverWithKey'](arg1, arg2);
+}
+
+export function RegenerateRecoveryKey() {
+  return window['go']['main']['App']['RegenerateRecoveryKey']();
+}
+
+export function RemoveBookFromSeries(arg1) {
+  return window['go']['main']['App']['RemoveBookFromSeries'](arg1);
+}
+
+export function RenameTag(arg1, arg2) {
+  return window['go']['main']['App']['RenameTag'](arg1, arg2);
+}
+
+export function ReportActivity() {
+  return window['go']['main']['App']['ReportActivity']();
+}
+
+export function RotateVaultKey(arg1, arg2) {
+  return window['go']['main']['App']['RotateVaultKey'](arg1, arg2);
+}
+
+export function SelectArchive() {
+  return window['go']['main']['App']['SelectArchive']();
+}
+
 export function SelectFolder() {
   return window['go']['main']['App']['SelectFolder']();
 }
@@ -58,14 +166,38 @@ export function SetBookCover(arg1, arg2) {
   return window['go']['main']['App']['SetBookCover'](arg1, arg2);
 }
 
+export function SetDecoyPassword(arg1) {
+  return window['go']['main']['App']['SetDecoyPassword'](arg1);
+}
+
 export function SetHiddenZonePassword(arg1) {
   return window['go']['main']['App']['SetHiddenZonePassword'](arg1);
 }
 
+export function SetImageSettings(arg1) {
+  return window['go']['main']['App']['SetImageSettings'](arg1);
+}
+
 export function SetMasterPassword(arg1) {
   return window['go']['main']['App']['SetMasterPassword'](arg1);
 }
 
+export function SetObfuscateNames(arg1) {
+  return window['go']['main']['App']['SetObfuscateNames'](arg1);
+}
+
+export function SetSessionSettings(arg1) {
+  return window['go']['main']['App']['SetSessionSettings'](arg1);
+}
+
+export function StartBatchImport(arg1, arg2) {
+  return window['go']['main']['App']['StartBatchImport'](arg1, arg2);
+}
+
+export function StartImport(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['StartImport'](arg1, arg2, arg3, arg4);
+}
+
 export function ToggleBookFavorite(arg1) {
   return window['go']['main']['App']['ToggleBookFavorite'](arg1);
 }
@@ -93,3 +225,7 @@ export function VerifyBookPassword(arg1, arg2) {
 export function VerifyPassword(arg1) {
   return window['go']['main']['App']['VerifyPassword'](arg1);
 }
+
+export function WindowHidden() {
+  return window['go']['main']['App']['WindowHidden']();
+}
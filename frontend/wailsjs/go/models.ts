@@ -1,6 +1,87 @@
 export namespace main {
 	
+	export class AuditEntryFrontend {
+	    id: number;
+	    time: number;
+	    event: string;
+	    target: string;
+	    success: boolean;
+	    detail: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditEntryFrontend(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.time = source["time"];
+	        this.event = source["event"];
+	        this.target = source["target"];
+	        this.success = source["success"];
+	        this.detail = source["detail"];
+	    }
+	}
+	export class AuditFilter {
+	    event: string;
+	    target: string;
+	    only_failures: boolean;
+	    since: number;
+	    limit: number;
+	    page: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.event = source["event"];
+	        this.target = source["target"];
+	        this.only_failures = source["only_failures"];
+	        this.since = source["since"];
+	        this.limit = source["limit"];
+	        this.page = source["page"];
+	    }
+	}
+	export class AuditLogResult {
+	    entries: AuditEntryFrontend[];
+	    total: number;
+	    intact: boolean;
+	    broken_at: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditLogResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], AuditEntryFrontend);
+	        this.total = source["total"];
+	        this.intact = source["intact"];
+	        this.broken_at = source["broken_at"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class BookFrontend {
+	    id: number;
 	    name: string;
 	    cover: string;
 	    tags: string[];
@@ -11,6 +92,14 @@ export namespace main {
 	    last_page: number;
 	    is_favorite: boolean;
 	    last_read_time: number;
+	    series_name: string;
+	    author: string;
+	    artist: string;
+	    volume: number;
+	    number: string;
+	    language: string;
+	    reading_direction: string;
+	    storage_profile: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new BookFrontend(source);
@@ -18,6 +107,7 @@ export namespace main {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
 	        this.name = source["name"];
 	        this.cover = source["cover"];
 	        this.tags = source["tags"];
@@ -28,8 +118,141 @@ export namespace main {
 	        this.last_page = source["last_page"];
 	        this.is_favorite = source["is_favorite"];
 	        this.last_read_time = source["last_read_time"];
+	        this.series_name = source["series_name"];
+	        this.author = source["author"];
+	        this.artist = source["artist"];
+	        this.volume = source["volume"];
+	        this.number = source["number"];
+	        this.language = source["language"];
+	        this.reading_direction = source["reading_direction"];
+	        this.storage_profile = source["storage_profile"];
+	    }
+	}
+	export class TagWithCount {
+	    name: string;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagWithCount(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.count = source["count"];
+	    }
+	}
+	export class DashboardStats {
+	    total_books: number;
+	    total_series: number;
+	    total_tags: number;
+	    top_tags: TagWithCount[];
+	    recent_books: BookFrontend[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DashboardStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total_books = source["total_books"];
+	        this.total_series = source["total_series"];
+	        this.total_tags = source["total_tags"];
+	        this.top_tags = this.convertValues(source["top_tags"], TagWithCount);
+	        this.recent_books = this.convertValues(source["recent_books"], BookFrontend);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProfileSettings {
+	    max_width: number;
+	    max_height: number;
+	    quality: number;
+	    filter: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.max_width = source["max_width"];
+	        this.max_height = source["max_height"];
+	        this.quality = source["quality"];
+	        this.filter = source["filter"];
+	    }
+	}
+	export class ImageSettings {
+	    compressed: ProfileSettings;
+	    lossless: ProfileSettings;
+	    thumb_width: number;
+	    thumb_format: string;
+	    thumb_quality: number;
+	    thumb_filter: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.compressed = this.convertValues(source["compressed"], ProfileSettings);
+	        this.lossless = this.convertValues(source["lossless"], ProfileSettings);
+	        this.thumb_width = source["thumb_width"];
+	        this.thumb_format = source["thumb_format"];
+	        this.thumb_quality = source["thumb_quality"];
+	        this.thumb_filter = source["thumb_filter"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImportOptions {
+	    prefer_sidecar: boolean;
+	    storage_profile: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.prefer_sidecar = source["prefer_sidecar"];
+	        this.storage_profile = source["storage_profile"];
 	    }
 	}
+	
 	export class SearchQuery {
 	    query: string;
 	    tags: string[];
@@ -37,6 +260,7 @@ export namespace main {
 	    only_fav: boolean;
 	    page: number;
 	    limit: number;
+	    series_id: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new SearchQuery(source);
@@ -50,6 +274,43 @@ export namespace main {
 	        this.only_fav = source["only_fav"];
 	        this.page = source["page"];
 	        this.limit = source["limit"];
+	        this.series_id = source["series_id"];
+	    }
+	}
+	export class SeriesFrontend {
+	    id: number;
+	    title: string;
+	    description: string;
+	    count: number;
+	    cover_book: string;
+	    cover_id: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SeriesFrontend(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.description = source["description"];
+	        this.count = source["count"];
+	        this.cover_book = source["cover_book"];
+	        this.cover_id = source["cover_id"];
+	    }
+	}
+	export class SessionSettings {
+	    idle_minutes: number;
+	    lock_on_blur: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.idle_minutes = source["idle_minutes"];
+	        this.lock_on_blur = source["lock_on_blur"];
 	    }
 	}
 
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/gen2brain/heic"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
+)
+
+// --- REGISTRY FORMAT GAMBAR ---
+//
+// Format halaman dikenali dari isi file (magic bytes), bukan ekstensi.
+// Saat scan, setiap file (selain sidecar) di-sniff; file yang tidak
+// dikenali, atau formatnya dikenali tapi belum punya decoder, dilewati dan
+// dicatat di ImportReport.Skipped.
+//
+// Format baru cukup ditambahkan lewat registerImageFormat (lihat init di
+// bawah). Decode nil = format dikenali tapi decodernya belum tersedia.
+//
+// GIF / WebP animasi (lihat Animated) selalu disimpan sebagai file aslinya,
+// apa pun profil penyimpanannya, supaya tetap bisa diputar di reader.
+
+const sniffLen = 512
+
+type imageFormat struct {
+	Name  string
+	MIME  string
+	Exts  []string // ekstensi yang dipakai di vault, Exts[0] = ekstensi utama
+	Web   bool     // bisa ditampilkan webview apa adanya (profil original)
+	Sniff func(head []byte) bool
+
+	Decode       func(io.Reader) (image.Image, error)
+	DecodeConfig func(io.Reader) (image.Config, error)
+	Animated     func(io.Reader) bool // opsional, r dibaca dari awal file
+}
+
+var imageFormats []*imageFormat
+
+func registerImageFormat(f *imageFormat) {
+	imageFormats = append(imageFormats, f)
+}
+
+func init() {
+	registerImageFormat(&imageFormat{
+		Name: "JPEG", MIME: "image/jpeg", Exts: []string{".jpg", ".jpeg"}, Web: true,
+		Sniff:  magic("\xff\xd8\xff"),
+		Decode: jpeg.Decode, DecodeConfig: jpeg.DecodeConfig,
+	})
+	registerImageFormat(&imageFormat{
+		Name: "PNG", MIME: "image/png", Exts: []string{".png"}, Web: true,
+		Sniff:  magic("\x89PNG\r\n\x1a\n"),
+		Decode: png.Decode, DecodeConfig: png.DecodeConfig,
+	})
+	registerImageFormat(&imageFormat{
+		Name: "WebP", MIME: "image/webp", Exts: []string{".webp"}, Web: true,
+		Sniff:  magic("RIFF????WEBPVP8"),
+		Decode: webp.Decode, DecodeConfig: webp.DecodeConfig,
+		Animated: webpAnimated,
+	})
+	registerImageFormat(&imageFormat{
+		Name: "GIF", MIME: "image/gif", Exts: []string{".gif"}, Web: true,
+		Sniff:  magic("GIF87a", "GIF89a"),
+		Decode: gif.Decode, DecodeConfig: gif.DecodeConfig,
+		Animated: gifAnimated,
+	})
+	registerImageFormat(&imageFormat{
+		Name: "BMP", MIME: "image/bmp", Exts: []string{".bmp"}, Web: true,
+		Sniff:  magic("BM????\x00\x00\x00\x00"),
+		Decode: bmp.Decode, DecodeConfig: bmp.DecodeConfig,
+	})
+	registerImageFormat(&imageFormat{
+		Name: "TIFF", MIME: "image/tiff", Exts: []string{".tif", ".tiff"},
+		Sniff:  magic("II*\x00", "MM\x00*"),
+		Decode: tiff.Decode, DecodeConfig: tiff.DecodeConfig,
+	})
+	// AVIF memakai container yang sama (sering juga ber-brand "mif1"), tapi
+	// belum ada decoder pure-Go yang dipakai: AVIF tidak dikenali, dilewati
+	avif := ftypBrand("avif", "avis")
+	heif := ftypBrand("heic", "heix", "hevc", "hevx", "heim", "heis", "mif1")
+	registerImageFormat(&imageFormat{
+		Name: "HEIC", MIME: "image/heic", Exts: []string{".heic", ".heif"},
+		Sniff:  func(head []byte) bool { return heif(head) && !avif(head) },
+		Decode: heic.Decode, DecodeConfig: heic.DecodeConfig,
+	})
+}
+
+// magic: cocok jika head diawali salah satu pola ("?" = byte apa saja).
+func magic(patterns ...string) func([]byte) bool {
+	return func(head []byte) bool {
+		for _, p := range patterns {
+			if len(head) < len(p) {
+				continue
+			}
+			match := true
+			for i := 0; i < len(p) && match; i++ {
+				match = p[i] == '?' || p[i] == head[i]
+			}
+			if match {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ftypBrand: container ISO-BMFF (AVIF/HEIC) dengan major atau compatible
+// brand tertentu di box "ftyp".
+func ftypBrand(brands ...string) func([]byte) bool {
+	return func(head []byte) bool {
+		if len(head) < 16 || string(head[4:8]) != "ftyp" {
+			return false
+		}
+		size := int(head[0])<<24 | int(head[1])<<16 | int(head[2])<<8 | int(head[3])
+		size = min(size, len(head))
+		for i := 8; i+4 <= size; i += 4 {
+			if i == 12 {
+				continue // minor version
+			}
+			for _, b := range brands {
+				if string(head[i:i+4]) == b {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// sniffImageFormat mengenali format dari awal isi file (nil = tidak dikenal).
+func sniffImageFormat(head []byte) *imageFormat {
+	for _, f := range imageFormats {
+		if f.Sniff(head) {
+			return f
+		}
+	}
+	return nil
+}
+
+// decodeImage men-decode gambar dari r sesuai format hasil sniff.
+func decodeImage(r io.Reader) (image.Image, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, _ := br.Peek(sniffLen)
+	f, err := decodableFormat(sniffImageFormat(head))
+	if err != nil {
+		return nil, err
+	}
+	return f.Decode(br)
+}
+
+func decodableFormat(f *imageFormat) (*imageFormat, error) {
+	switch {
+	case f == nil:
+		return nil, fmt.Errorf("format gambar tidak dikenal")
+	case f.Decode == nil:
+		return nil, fmt.Errorf("decoder %s belum tersedia", f.Name)
+	}
+	return f, nil
+}
+
+// sniffPage membaca awal isi halaman dan mengembalikan formatnya, serta
+// apakah gambarnya animasi.
+func sniffPage(page importPage) (*imageFormat, bool, error) {
+	r, err := page.Open()
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, false, err
+	}
+	f, err := decodableFormat(sniffImageFormat(head[:n]))
+	if err != nil {
+		return nil, false, err
+	}
+	animated := f.Animated != nil && f.Animated(io.MultiReader(bytes.NewReader(head[:n]), r))
+	return f, animated, nil
+}
+
+// classifyPages mengisi Format tiap halaman. Halaman yang tidak bisa
+// di-decode dibuang dari daftar dan dikembalikan sebagai skipped.
+func classifyPages(pages []importPage) ([]importPage, []ImportFailure) {
+	var ok []importPage
+	var skipped []ImportFailure
+	for _, p := range pages {
+		if p.Decode != nil {
+			ok = append(ok, p) // piksel mentah (PDF), tidak perlu sniff
+			continue
+		}
+		f, animated, err := sniffPage(p)
+		if err != nil {
+			skipped = append(skipped, ImportFailure{File: p.Rel, Error: err.Error()})
+			continue
+		}
+		p.Format, p.Animated = f, animated
+		ok = append(ok, p)
+	}
+	return ok, skipped
+}
+
+// readConfig membaca ukuran gambar tanpa decode penuh.
+func (f *imageFormat) readConfig(data []byte) (image.Config, error) {
+	if f.DecodeConfig == nil {
+		return image.Config{}, fmt.Errorf("decoder %s belum tersedia", f.Name)
+	}
+	return f.DecodeConfig(bytes.NewReader(data))
+}
+
+// isImageFile: nama file berekstensi gambar yang dikenal (dipakai untuk
+// daftar halaman di folder buku, bukan untuk scan import).
+func isImageFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, f := range imageFormats {
+		for _, e := range f.Exts {
+			if e == ext {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// webpAnimated: flag animasi di chunk VP8X (extended format).
+func webpAnimated(r io.Reader) bool {
+	var head [21]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return false
+	}
+	return string(head[12:16]) == "VP8X" && head[20]&0x02 != 0
+}
+
+// gifAnimated: GIF berisi lebih dari satu frame. Hanya struktur blok yang
+// dibaca, data LZW dilewati tanpa di-decode.
+func gifAnimated(r io.Reader) bool {
+	br := bufio.NewReader(r)
+	var header [13]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return false
+	}
+	if header[10]&0x80 != 0 {
+		br.Discard(3 << (header[10]&0x07 + 1)) // global color table
+	}
+	frames := 0
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return false
+		}
+		switch b {
+		case 0x2c: // image descriptor
+			if frames++; frames > 1 {
+				return true
+			}
+			var desc [9]byte
+			if _, err := io.ReadFull(br, desc[:]); err != nil {
+				return false
+			}
+			if desc[8]&0x80 != 0 {
+				br.Discard(3 << (desc[8]&0x07 + 1)) // local color table
+			}
+			br.ReadByte() // LZW minimum code size
+		case 0x21: // extension
+			br.ReadByte() // label
+		default: // 0x3b trailer, atau data rusak
+			return false
+		}
+		if skipGIFSubBlocks(br) != nil {
+			return false
+		}
+	}
+}
+
+func skipGIFSubBlocks(br *bufio.Reader) error {
+	for {
+		n, err := br.ReadByte()
+		if err != nil || n == 0 {
+			return err
+		}
+		if _, err := br.Discard(int(n)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+// ftypHead: awal file ISO-BMFF dengan major brand dan compatible brand.
+func ftypHead(major string, compatible ...string) []byte {
+	size := 16 + 4*len(compatible)
+	head := []byte{0, 0, 0, byte(size)}
+	head = append(head, "ftyp"+major+"\x00\x00\x00\x00"...)
+	for _, b := range compatible {
+		head = append(head, b...)
+	}
+	return append(head, "\x00\x00\x00\x08meta"...)
+}
+
+func TestSniffImageFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string // "" = tidak dikenal
+	}{
+		{"JPEG", []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), "JPEG"},
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), "PNG"},
+		{"WebP", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "WebP"},
+		{"HEIC", ftypHead("heic", "mif1", "heic"), "HEIC"},
+		{"HEIF mif1", ftypHead("mif1", "mif1", "heix"), "HEIC"},
+		// AVIF belum punya decoder: tidak boleh masuk ke decoder HEIC
+		{"AVIF", ftypHead("avif", "mif1", "miaf"), ""},
+		{"AVIF brand mif1", ftypHead("mif1", "avif", "miaf"), ""},
+		{"AVIF sequence", ftypHead("avis", "avif", "msf1"), ""},
+		{"MP4", ftypHead("isom", "isom", "mp41"), ""},
+		{"terlalu pendek", []byte("\x00\x00\x00\x10ftyp"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if f := sniffImageFormat(tt.head); f != nil {
+				got = f.Name
+			}
+			if got != tt.want {
+				t.Errorf("sniffImageFormat = %q, ingin %q", got, tt.want)
+			}
+		})
+	}
+}
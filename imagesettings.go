@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+// --- PENGATURAN KOMPRESI GAMBAR ---
+//
+// Disimpan sebagai JSON di GlobalConfig ("image_settings"). Field yang
+// belum pernah diatur memakai defaultImageSettings. Dipakai importImage
+// (profil compressed / lossless, lihat storage.go) dan serveThumbnail.
+
+const configImageSettings = "image_settings"
+
+// ProfileSettings: pengolahan halaman untuk satu profil penyimpanan.
+type ProfileSettings struct {
+	MaxWidth  int    `json:"max_width"`  // 0 = tanpa batas
+	MaxHeight int    `json:"max_height"` // 0 = tanpa batas (webtoon)
+	Quality   int    `json:"quality"`    // kualitas JPEG 1-100 (compressed saja)
+	Filter    string `json:"filter"`     // filter resize, lihat resampleFilters
+}
+
+type ImageSettings struct {
+	Compressed ProfileSettings `json:"compressed"`
+	Lossless   ProfileSettings `json:"lossless"` // resize hanya jika batas diisi
+
+	ThumbWidth   int    `json:"thumb_width"`
+	ThumbFormat  string `json:"thumb_format"` // "jpeg" / "png"
+	ThumbQuality int    `json:"thumb_quality"`
+	ThumbFilter  string `json:"thumb_filter"` // filter resize, lihat resampleFilters
+}
+
+var defaultImageSettings = ImageSettings{
+	Compressed:   ProfileSettings{MaxWidth: 1920, Quality: 75, Filter: "lanczos"},
+	Lossless:     ProfileSettings{Filter: "lanczos"},
+	ThumbWidth:   300,
+	ThumbFormat:  "jpeg",
+	ThumbQuality: 75,
+	ThumbFilter:  "lanczos",
+}
+
+var resampleFilters = map[string]imaging.ResampleFilter{
+	"lanczos":    imaging.Lanczos,
+	"catmullrom": imaging.CatmullRom,
+	"linear":     imaging.Linear,
+	"box":        imaging.Box,
+	"nearest":    imaging.NearestNeighbor,
+}
+
+const (
+	minImageSide = 64
+	maxImageSide = 16384
+	maxThumbSide = 1024
+)
+
+func (a *App) GetImageSettings() ImageSettings {
+	s := defaultImageSettings
+	if raw := a.getConfig(configImageSettings); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &s); err != nil {
+			return defaultImageSettings
+		}
+	}
+	return s
+}
+
+func (a *App) SetImageSettings(s ImageSettings) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	old := a.GetImageSettings()
+	a.setConfig(configImageSettings, string(raw))
+	if old.thumbSpec() != s.thumbSpec() {
+		// Thumbnail lama dibuat dengan ukuran / format berbeda
+		a.ClearThumbnailCache()
+	}
+	return nil
+}
+
+func (s ImageSettings) validate() error {
+	for name, p := range map[string]ProfileSettings{"compressed": s.Compressed, "lossless": s.Lossless} {
+		if err := p.validate(name == "compressed"); err != nil {
+			return fmt.Errorf("profil %s: %w", name, err)
+		}
+	}
+	if s.ThumbWidth < minImageSide || s.ThumbWidth > maxThumbSide {
+		return fmt.Errorf("lebar thumbnail harus antara %d dan %d", minImageSide, maxThumbSide)
+	}
+	switch s.ThumbFormat {
+	case "jpeg":
+		if s.ThumbQuality < 1 || s.ThumbQuality > 100 {
+			return fmt.Errorf("kualitas thumbnail harus antara 1 dan 100")
+		}
+	case "png":
+	default:
+		return fmt.Errorf("format thumbnail tidak dikenal: %s", s.ThumbFormat)
+	}
+	if _, ok := resampleFilters[s.ThumbFilter]; !ok {
+		return fmt.Errorf("filter thumbnail tidak dikenal: %s", s.ThumbFilter)
+	}
+	return nil
+}
+
+func (p ProfileSettings) validate(needQuality bool) error {
+	for _, side := range []int{p.MaxWidth, p.MaxHeight} {
+		if side != 0 && (side < minImageSide || side > maxImageSide) {
+			return fmt.Errorf("batas ukuran harus 0 (tanpa batas) atau antara %d dan %d", minImageSide, maxImageSide)
+		}
+	}
+	if needQuality && (p.Quality < 1 || p.Quality > 100) {
+		return fmt.Errorf("kualitas harus antara 1 dan 100")
+	}
+	if _, ok := resampleFilters[p.Filter]; !ok {
+		return fmt.Errorf("filter tidak dikenal: %s", p.Filter)
+	}
+	return nil
+}
+
+// thumbSpec: identitas pengaturan thumbnail (ikut nama file cache).
+func (s ImageSettings) thumbSpec() string {
+	return fmt.Sprintf("%d:%s:%d:%s", s.ThumbWidth, s.ThumbFormat, s.ThumbQuality, s.ThumbFilter)
+}
+
+func (s ImageSettings) thumbMIME() string {
+	if s.ThumbFormat == "png" {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+// encodeThumbnail mengecilkan cover sesuai pengaturan thumbnail.
+func (s ImageSettings) encodeThumbnail(img image.Image) ([]byte, error) {
+	thumb := imaging.Resize(img, s.ThumbWidth, 0, resampleFilter(s.ThumbFilter))
+	var buf bytes.Buffer
+	var err error
+	if s.ThumbFormat == "png" {
+		err = imaging.Encode(&buf, thumb, imaging.PNG)
+	} else {
+		err = imaging.Encode(&buf, thumb, imaging.JPEG, imaging.JPEGQuality(s.ThumbQuality))
+	}
+	return buf.Bytes(), err
+}
+
+// fit mengecilkan img supaya muat di MaxWidth x MaxHeight (rasio tetap).
+// Gambar yang sudah cukup kecil tidak diubah.
+func (p ProfileSettings) fit(img image.Image) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	scale := 1.0
+	if p.MaxWidth > 0 && w > p.MaxWidth {
+		scale = float64(p.MaxWidth) / float64(w)
+	}
+	if p.MaxHeight > 0 && h > p.MaxHeight {
+		scale = min(scale, float64(p.MaxHeight)/float64(h))
+	}
+	if scale >= 1 {
+		return img
+	}
+	return imaging.Resize(img, max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5)), resampleFilter(p.Filter))
+}
+
+// resampleFilter: filter resize dari namanya (Lanczos jika tidak dikenal).
+func resampleFilter(name string) imaging.ResampleFilter {
+	if filter, ok := resampleFilters[name]; ok {
+		return filter
+	}
+	return imaging.Lanczos
+}
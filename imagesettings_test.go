@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestImageSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(s *ImageSettings)
+		wantErr bool
+	}{
+		{"default", func(s *ImageSettings) {}, false},
+		{"thumb filter nearest", func(s *ImageSettings) { s.ThumbFilter = "nearest" }, false},
+		{"thumb filter kosong", func(s *ImageSettings) { s.ThumbFilter = "" }, true},
+		{"thumb filter tidak dikenal", func(s *ImageSettings) { s.ThumbFilter = "bicubic" }, true},
+		{"filter profil tidak dikenal", func(s *ImageSettings) { s.Compressed.Filter = "bicubic" }, true},
+		{"thumbnail terlalu lebar", func(s *ImageSettings) { s.ThumbWidth = maxThumbSide + 1 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := defaultImageSettings
+			tt.edit(&s)
+			if err := s.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Filter thumbnail dipakai saat encode dan ikut identitas cache.
+func TestEncodeThumbnailFilter(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 256, 256))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			if (x+y)%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	s := defaultImageSettings
+	s.ThumbWidth, s.ThumbFormat = 64, "png"
+	lanczos, err := s.encodeThumbnail(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := s
+	n.ThumbFilter = "nearest"
+	nearest, err := n.encodeThumbnail(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(lanczos, nearest) {
+		t.Error("thumbnail sama untuk filter lanczos dan nearest")
+	}
+	if s.thumbSpec() == n.thumbSpec() {
+		t.Error("thumbSpec tidak berubah saat filter thumbnail diganti")
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// --- IMPORT DI BACKGROUND ---
+//
+// StartImport / StartBatchImport langsung mengembalikan ID job, import
+// berjalan di goroutine sendiri. Job dijalankan bergantian (satu per satu),
+// karena satu import sudah memakai semua core CPU.
+//
+// Event ke frontend:
+//
+//	"import:progress"  ImportProgress, paling sering tiap importProgressInterval
+//	"import:done"      ImportJobResult, laporan lengkap per buku termasuk
+//	                   daftar file yang gagal / dilewati beserta alasannya
+//
+// CancelImport membatalkan job lewat context. Buku baru yang dibatalkan
+// tidak disimpan; pada sync, halaman yang sudah tertulis tetap dihitung.
+// Mengunci vault otomatis membatalkan semua job; import-nya dilanjutkan
+// setelah unlock berikutnya (lihat importtx.go).
+
+const importProgressInterval = 200 * time.Millisecond
+
+type ImportProgress struct {
+	JobID     string `json:"job_id"`
+	Book      string `json:"book"`
+	BookIndex int    `json:"book_index"` // batch: buku ke-berapa (mulai 1)
+	BookCount int    `json:"book_count"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+	Failed    int    `json:"failed"`
+	Bytes     int64  `json:"bytes"`
+	Current   string `json:"current"`
+	ETA       int    `json:"eta_seconds"` // -1 = belum bisa diperkirakan
+}
+
+type ImportFailure struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// ImportReport: hasil import satu buku.
+type ImportReport struct {
+	Book     string          `json:"book"`
+	Imported int             `json:"imported"`
+	Total    int             `json:"total"`
+	Bytes    int64           `json:"bytes"`
+	Failed   []ImportFailure `json:"failed"`
+	Skipped  []ImportFailure `json:"skipped"` // file yang tidak dikenali / tanpa decoder
+	Canceled bool            `json:"canceled"`
+	Error    string          `json:"error,omitempty"` // buku dilewati (sumber rusak, sudah ada, ...)
+}
+
+// Message: ringkasan satu baris (format lama CreateBook).
+func (r ImportReport) Message() string {
+	var msg string
+	switch {
+	case r.Error != "":
+		msg = r.Error
+	case r.Canceled:
+		msg = fmt.Sprintf("Import dibatalkan (%d gambar sudah diimpor).", r.Imported)
+	case len(r.Failed) > 0:
+		msg = fmt.Sprintf("Sukses! %d gambar diimpor, %d gagal.", r.Imported, len(r.Failed))
+	default:
+		msg = fmt.Sprintf("Sukses! %d gambar diimpor (Parallel Mode).", r.Imported)
+	}
+	if len(r.Skipped) > 0 {
+		msg += fmt.Sprintf(" %d file dilewati (format tidak didukung).", len(r.Skipped))
+	}
+	return msg
+}
+
+type ImportJobResult struct {
+	JobID    string         `json:"job_id"`
+	Reports  []ImportReport `json:"reports"`
+	Canceled bool           `json:"canceled"`
+}
+
+// importResult: hasil satu halaman dari imageWorker.
+type importResult struct {
+	Rel   string
+	Bytes int64
+	Err   error
+}
+
+// StartImport menjalankan CreateBook di background. Lihat event
+// "import:progress" dan "import:done".
+func (a *App) StartImport(bookName, sourcePath string, syncMode bool, opts ImportOptions) (string, error) {
+	if bookName == "" || sourcePath == "" {
+		return "", fmt.Errorf("data kosong")
+	}
+	if !a.IsVaultUnlocked() {
+		return "", ErrVaultLocked
+	}
+	return a.startImportJob(func(ctx context.Context, tracker *importTracker) []ImportReport {
+		tracker.nextBook(1, 1)
+		return []ImportReport{a.importBook(ctx, tracker, bookName, sourcePath, syncMode, opts)}
+	}), nil
+}
+
+// StartBatchImport menjalankan BatchImportBooks di background.
+func (a *App) StartBatchImport(rootPath string, opts ImportOptions) (string, error) {
+	if !a.IsVaultUnlocked() {
+		return "", ErrVaultLocked
+	}
+	items, err := batchImportItems(rootPath)
+	if err != nil {
+		return "", fmt.Errorf("gagal membaca folder: %w", err)
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("tidak ada folder / file buku di %s", rootPath)
+	}
+	return a.startImportJob(func(ctx context.Context, tracker *importTracker) []ImportReport {
+		return a.importBatch(ctx, tracker, items, opts)
+	}), nil
+}
+
+// CancelImport membatalkan job import yang sedang berjalan / menunggu giliran.
+func (a *App) CancelImport(jobID string) error {
+	a.importMu.Lock()
+	cancel, ok := a.importJobs[jobID]
+	a.importMu.Unlock()
+	if !ok {
+		return fmt.Errorf("job import tidak ditemukan")
+	}
+	cancel()
+	return nil
+}
+
+func (a *App) startImportJob(run func(ctx context.Context, tracker *importTracker) []ImportReport) string {
+	id := opaqueName()[:12]
+	ctx, cancel := context.WithCancel(context.Background())
+	a.importMu.Lock()
+	a.importJobs[id] = cancel
+	a.importMu.Unlock()
+
+	// Jangan auto-lock (dan menghapus kunci) selama job menunggu / berjalan
+	release := a.holdSession()
+	go func() {
+		defer release()
+		a.importRunMu.Lock()
+		var reports []ImportReport
+		if ctx.Err() == nil {
+			reports = run(ctx, newImportTracker(a, id))
+		}
+		a.importRunMu.Unlock()
+
+		a.importMu.Lock()
+		delete(a.importJobs, id)
+		a.importMu.Unlock()
+		canceled := ctx.Err() != nil
+		cancel()
+		a.emit("import:done", ImportJobResult{JobID: id, Reports: reports, Canceled: canceled})
+	}()
+	return id
+}
+
+// cancelImports membatalkan semua job (dipanggil saat vault dikunci).
+func (a *App) cancelImports() {
+	a.importMu.Lock()
+	defer a.importMu.Unlock()
+	for _, cancel := range a.importJobs {
+		cancel()
+	}
+}
+
+// importTracker menghitung progress dan mengirim "import:progress".
+// Job sinkron (CreateBook) memakai tracker tanpa ID, event-nya tidak dikirim.
+type importTracker struct {
+	app      *App
+	progress ImportProgress
+	start    time.Time
+	lastEmit time.Time
+}
+
+func newImportTracker(a *App, jobID string) *importTracker {
+	return &importTracker{app: a, progress: ImportProgress{JobID: jobID, BookIndex: 1, BookCount: 1}}
+}
+
+func (t *importTracker) nextBook(index, count int) {
+	t.progress.BookIndex, t.progress.BookCount = index, count
+}
+
+// begin dipanggil setelah scan selesai dan jumlah halaman diketahui.
+func (t *importTracker) begin(book string, total int) {
+	p := &t.progress
+	p.Book, p.Total = book, total
+	p.Done, p.Failed, p.Bytes, p.Current, p.ETA = 0, 0, 0, "", -1
+	t.start = time.Now()
+	t.emit()
+}
+
+func (t *importTracker) add(res importResult) {
+	p := &t.progress
+	p.Done++
+	p.Bytes += res.Bytes
+	p.Current = res.Rel
+	if res.Err != nil {
+		p.Failed++
+	}
+	elapsed := time.Since(t.start)
+	p.ETA = int((elapsed * time.Duration(p.Total-p.Done) / time.Duration(p.Done)).Seconds())
+	if p.Done == p.Total || time.Since(t.lastEmit) >= importProgressInterval {
+		t.emit()
+	}
+}
+
+func (t *importTracker) emit() {
+	if t.progress.JobID == "" {
+		return
+	}
+	t.lastEmit = time.Now()
+	t.app.emit("import:progress", t.progress)
+}
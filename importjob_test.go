@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// assertNoBook: buku yang dibatalkan tidak tersimpan sama sekali, baik di
+// library maupun di folder vault.
+func assertNoBook(t *testing.T, a *App, j *ImportJournal) {
+	t.Helper()
+	var count int64
+	a.db.Model(&Book{}).Where("title = ?", j.BookName).Count(&count)
+	if count != 0 {
+		t.Errorf("buku %q tersimpan setelah dibatalkan", j.BookName)
+	}
+	if _, err := os.Stat(j.DestPath); !os.IsNotExist(err) {
+		t.Errorf("folder buku %s ada setelah dibatalkan", j.DestPath)
+	}
+	assertNoStaging(t, a)
+}
+
+// Dibatalkan di tengah jalan, setelah sebagian halaman ditulis ke staging.
+func TestCancelImportLeavesNoPartialBook(t *testing.T) {
+	a := newTestApp(t)
+	src := writeTestPages(t, 12)
+	j, pages := beginTestImport(t, a, "Batal", src)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var opened atomic.Int32
+	for rel, p := range pages {
+		open := p.Open
+		p.Open = func() (io.ReadCloser, error) {
+			if opened.Add(1) == 4 {
+				cancel()
+			}
+			return open()
+		}
+		pages[rel] = p
+	}
+
+	key := a.sessionKey()
+	defer wipeBytes(key)
+	report := a.runImportJournal(ctx, newImportTracker(a, ""), j, pages, key)
+	if !report.Canceled {
+		t.Fatalf("report tidak dibatalkan: %+v", report)
+	}
+	if report.Imported != 0 {
+		t.Errorf("Imported = %d, want 0", report.Imported)
+	}
+	if len(report.Failed) != 0 {
+		t.Errorf("halaman yang dibatalkan dilaporkan gagal: %v", report.Failed)
+	}
+	assertNoBook(t, a, j)
+}
+
+// Job yang dibatalkan sebelum gilirannya tidak menyentuh vault sama sekali.
+func TestCancelQueuedImport(t *testing.T) {
+	a := newTestApp(t)
+	src := writeTestPages(t, 3)
+
+	a.importRunMu.Lock() // job lain sedang berjalan
+	id, err := a.StartImport("Antre", src, false, ImportOptions{})
+	if err != nil {
+		a.importRunMu.Unlock()
+		t.Fatal(err)
+	}
+	if err := a.CancelImport(id); err != nil {
+		a.importRunMu.Unlock()
+		t.Fatal(err)
+	}
+	a.importRunMu.Unlock()
+	waitImportJobs(t, a)
+
+	if err := a.CancelImport(id); err == nil {
+		t.Error("job yang sudah selesai masih bisa dibatalkan")
+	}
+	assertNoBook(t, a, &ImportJournal{BookName: "Antre", DestPath: filepath.Join(a.vaultDir, "Antre")})
+}
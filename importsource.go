@@ -0,0 +1,242 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- SUMBER IMPORT (FOLDER / ARSIP / PDF / EPUB) ---
+//
+// CreateBook menerima folder biasa atau file buku (.cbz/.zip/.pdf/.epub). Isi arsip
+// dibaca langsung dari zip (tidak diekstrak ke folder temp polos), folder
+// di dalam arsip menjadi chapter, dan halaman diurutkan dengan natsort.
+
+// Batas ukuran satu gambar di dalam arsip (melindungi dari zip bomb)
+const maxArchiveEntrySize = 256 << 20
+
+// importPage: satu halaman yang akan diimpor.
+type importPage struct {
+	Rel  string // path relatif pakai "/", mis. "Chapter 1/001.jpg"
+	Open func() (io.ReadCloser, error)
+	// Decode opsional, untuk sumber yang bukan file gambar utuh
+	// (mis. piksel mentah di PDF). Jika nil, hasil Open yang di-decode.
+	Decode func() (image.Image, error)
+	// Format hasil sniff saat scan (lihat imageformat.go), nil jika Decode diisi
+	Format   *imageFormat
+	Animated bool // GIF / WebP animasi, disimpan sebagai file asli
+}
+
+func (p importPage) decode() (image.Image, error) {
+	if p.Decode != nil {
+		return p.Decode()
+	}
+	r, err := p.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	if p.Format != nil && p.Format.Decode != nil {
+		return p.Format.Decode(r)
+	}
+	return decodeImage(r)
+}
+
+// bookMetadata: metadata buku yang dibawa sumber import (OPF di EPUB,
+// ComicInfo.xml / info.json, lihat sidecar.go).
+type bookMetadata struct {
+	Title       string
+	Author      string // penulis
+	Artist      string
+	Series      string
+	Volume      int
+	Number      string // nomor chapter/issue, mis. "12.5"
+	Description string
+	Tags        []string
+	Language    string
+	Direction   string // readingLTR / readingRTL, "" = tidak diketahui
+}
+
+// ImportOptions: opsi per import dari frontend.
+type ImportOptions struct {
+	// PreferSidecar: pakai judul dari metadata (ComicInfo.xml, info.json,
+	// OPF) alih-alih nama folder / file. Field lain (penulis, series, tag,
+	// ...) selalu dipakai. Judul OPF .epub juga dipakai jika nama buku
+	// masih nama filenya.
+	PreferSidecar bool `json:"prefer_sidecar"`
+	// StorageProfile: "compressed" (default), "lossless" atau "original"
+	// (lihat storage.go). Diabaikan saat sync, buku memakai profilnya sendiri.
+	StorageProfile string `json:"storage_profile"`
+}
+
+// importSource: hasil scan satu sumber import.
+type importSource struct {
+	Pages    []importPage
+	Meta     *bookMetadata   // nil jika sumber tidak membawa metadata
+	Embedded bool            // Meta dari file buku itu sendiri (OPF .epub), bukan sidecar
+	Skipped  []ImportFailure // file yang tidak bisa di-decode (lihat classifyPages)
+	closer   io.Closer       // file arsip yang harus tetap terbuka selama import
+}
+
+func (s *importSource) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// importFileScanners: format file yang bisa diimpor sebagai satu buku.
+var importFileScanners = map[string]func(string) (*importSource, error){
+	".cbz":  scanZip,
+	".zip":  scanZip,
+	".pdf":  scanPDF,
+	".epub": scanEPUB,
+}
+
+func isImportFile(name string) bool {
+	_, ok := importFileScanners[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// archiveBookName: judul default buku dari nama file arsip / PDF / EPUB.
+func archiveBookName(name string) string {
+	return strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+}
+
+// scanImportSource mengumpulkan halaman dari folder atau file buku, sudah
+// diurutkan natural. Format tiap halaman dikenali dari isinya; file yang
+// tidak bisa di-decode masuk Skipped. Close harus dipanggil setelah semua
+// halaman dibaca.
+func scanImportSource(sourcePath string) (*importSource, error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	var src *importSource
+	if info.IsDir() {
+		src = scanFolder(sourcePath)
+	} else {
+		scan, ok := importFileScanners[strings.ToLower(filepath.Ext(sourcePath))]
+		if !ok {
+			return nil, fmt.Errorf("format file tidak didukung: %s", filepath.Ext(sourcePath))
+		}
+		if src, err = scan(sourcePath); err != nil {
+			return nil, err
+		}
+	}
+	pages, skipped := classifyPages(sortPages(src.Pages))
+	src.Pages, src.Skipped = pages, append(src.Skipped, skipped...)
+	return src, nil
+}
+
+func scanFolder(root string) *importSource {
+	var pages []importPage
+	var sidecars []sidecarFile
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		open := func() (io.ReadCloser, error) { return os.Open(p) }
+		switch {
+		case strings.HasPrefix(d.Name(), "."):
+			// File tersembunyi (.DS_Store, ...) bukan halaman
+		case sidecarRank(rel) > 0:
+			sidecars = append(sidecars, sidecarFile{Rel: rel, Open: open})
+		default:
+			// Format dicek dari isi file nanti (classifyPages)
+			pages = append(pages, importPage{Rel: rel, Open: open})
+		}
+		return nil
+	})
+	return &importSource{Pages: pages, Meta: readSidecars(sidecars)}
+}
+
+func scanZip(archivePath string) (*importSource, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka arsip: %w", err)
+	}
+	var pages []importPage
+	var sidecars []sidecarFile
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || f.UncompressedSize64 > maxArchiveEntrySize {
+			continue
+		}
+		rel := cleanArchivePath(f.Name)
+		switch {
+		case rel == "":
+		case sidecarRank(rel) > 0:
+			sidecars = append(sidecars, sidecarFile{Rel: rel, Open: f.Open})
+		default:
+			pages = append(pages, importPage{Rel: rel, Open: f.Open})
+		}
+	}
+	return &importSource{
+		Pages:  stripCommonRoot(pages),
+		Meta:   readSidecars(sidecars),
+		closer: zr,
+	}, nil
+}
+
+// cleanArchivePath menormalkan nama entry zip. Entry sampah (__MACOSX,
+// file tersembunyi) dibuang, dan ".." tidak boleh keluar dari folder buku.
+func cleanArchivePath(name string) string {
+	var parts []string
+	for _, p := range strings.Split(strings.ReplaceAll(name, `\`, "/"), "/") {
+		switch {
+		case p == "" || p == "." || p == "..":
+			continue
+		case p == "__MACOSX" || strings.HasPrefix(p, "."):
+			return ""
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// stripCommonRoot: arsip yang semua isinya ada di satu folder pembungkus
+// ("Judul/Chapter 1/001.jpg") diperlakukan seperti folder itu sendiri.
+func stripCommonRoot(pages []importPage) []importPage {
+	if len(pages) == 0 {
+		return pages
+	}
+	root, _, ok := strings.Cut(pages[0].Rel, "/")
+	if !ok {
+		return pages
+	}
+	prefix := root + "/"
+	for _, p := range pages {
+		if !strings.HasPrefix(p.Rel, prefix) {
+			return pages
+		}
+	}
+	for i := range pages {
+		pages[i].Rel = strings.TrimPrefix(pages[i].Rel, prefix)
+	}
+	return pages
+}
+
+// sortPages mengurutkan halaman dengan natsort (entry ganda dibuang).
+func sortPages(pages []importPage) []importPage {
+	byRel := make(map[string]importPage, len(pages))
+	rels := make([]string, 0, len(pages))
+	for _, p := range pages {
+		if _, dup := byRel[p.Rel]; !dup {
+			byRel[p.Rel] = p
+			rels = append(rels, p.Rel)
+		}
+	}
+	natsort(rels)
+	sorted := make([]importPage, len(rels))
+	for i, rel := range rels {
+		sorted[i] = byRel[rel]
+	}
+	return sorted
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// --- IMPORT TRANSAKSIONAL ---
+//
+// Halaman tidak langsung ditulis ke folder buku, tapi ke folder staging
+// <profil>/staging/<acak> dengan struktur yang sama. Rencana import
+// (ImportJournal + ImportJournalTask) disimpan di library terenkripsi
+// SEBELUM file pertama ditulis.
+//
+// Commit:
+//   - buku baru: baris Book + VaultEntry dibuat, jurnal dihapus, lalu folder
+//     staging di-rename menjadi folder buku, semuanya dalam satu transaksi
+//     (rename gagal = rollback).
+//   - sync: file staging dipindah satu per satu ke folder buku, lalu
+//     VaultEntry + hitung ulang total_pages + hapus jurnal dalam satu
+//     transaksi.
+//
+// Jika aplikasi crash / vault dikunci di tengah import, jurnal masih ada di
+// library. Jurnal baru bisa dibaca setelah unlock, jadi resumeImports
+// dipanggil dari unlockSession: halaman yang sudah ada di staging (atau
+// sudah dipindah) dilewati, sisanya diimpor ulang dari sumber, lalu commit.
+// Folder staging tanpa jurnal dihapus.
+
+func (a *App) stagingRoot() string {
+	return filepath.Join(a.profileDir(), "staging")
+}
+
+func (a *App) stagingPath(j *ImportJournal) string {
+	return filepath.Join(a.stagingRoot(), j.Staging)
+}
+
+func (j *ImportJournal) destFile(t *ImportJournalTask) string {
+	return filepath.Join(j.DestPath, filepath.FromSlash(t.Dest))
+}
+
+// beginImport membuat folder staging dan menyimpan jurnal ke library.
+func (a *App) beginImport(j *ImportJournal) error {
+	j.Staging = opaqueName()
+	if err := os.MkdirAll(a.stagingPath(j), 0755); err != nil {
+		return err
+	}
+	if err := a.libraryDB().Create(j).Error; err != nil {
+		os.RemoveAll(a.stagingPath(j))
+		return err
+	}
+	// Jurnal harus sudah tersimpan sebelum file pertama ditulis
+	if err := a.flushLibrary(); err != nil {
+		a.libraryDB().Delete(j)
+		os.RemoveAll(a.stagingPath(j))
+		return err
+	}
+	return nil
+}
+
+// runImportJournal mengimpor halaman jurnal yang belum ada di staging,
+// lalu commit. pages: halaman sumber per Rel (boleh kurang saat resume).
+func (a *App) runImportJournal(ctx context.Context, tracker *importTracker, j *ImportJournal, pages map[string]importPage, key []byte) ImportReport {
+	report := ImportReport{Book: j.BookName, Total: len(j.Tasks)}
+	stage := a.stagingPath(j)
+	settings := a.GetImageSettings()
+
+	var jobs []ImportJob
+	for i := range j.Tasks {
+		t := &j.Tasks[i]
+		staged := filepath.Join(stage, filepath.FromSlash(t.Dest))
+		if fileExists(staged) || fileExists(j.destFile(t)) {
+			continue // sudah ditulis sebelum import terputus
+		}
+		page, ok := pages[t.Rel]
+		if !ok {
+			report.Failed = append(report.Failed, ImportFailure{File: t.Rel, Error: "file sumber tidak ditemukan"})
+			continue
+		}
+		os.MkdirAll(filepath.Dir(staged), 0755)
+		jobs = append(jobs, ImportJob{Page: page, Profile: j.StorageProfile, Settings: settings, DestPath: staged, Key: key})
+	}
+	a.processImportJobs(ctx, tracker, j.BookName, jobs, &report)
+
+	if ctx.Err() != nil {
+		report.Canceled = true
+		if !a.IsVaultUnlocked() {
+			// Vault dikunci: jurnal & staging dibiarkan, lanjut saat unlock
+			return report
+		}
+		if j.BookID == 0 {
+			// Buku baru yang dibatalkan tidak disimpan setengah jadi
+			a.abortImport(j)
+			report.Imported = 0
+			return report
+		}
+	}
+
+	n, err := a.commitImport(j)
+	report.Imported = n
+	if err != nil {
+		report.Error = "Gagal: " + err.Error()
+	}
+	return report
+}
+
+// commitImport memindahkan hasil staging ke folder buku dan mencatatnya
+// ke library. Aman diulang (resume setelah crash di tengah commit).
+func (a *App) commitImport(j *ImportJournal) (int, error) {
+	if !a.inVault(j.DestPath) {
+		a.abortImport(j)
+		return 0, errOutsideVault
+	}
+	stage := a.stagingPath(j)
+	removeTmpFiles(stage)
+
+	var book Book
+	if j.BookID != 0 {
+		if err := a.libraryDB().First(&book, j.BookID).Error; err != nil {
+			a.abortImport(j)
+			return 0, fmt.Errorf("buku sudah dihapus")
+		}
+	}
+
+	// Halaman yang berhasil: ada di staging, atau sudah dipindah
+	var done []*ImportJournalTask
+	for i := range j.Tasks {
+		t := &j.Tasks[i]
+		if fileExists(filepath.Join(stage, filepath.FromSlash(t.Dest))) || fileExists(j.destFile(t)) {
+			done = append(done, t)
+		}
+	}
+	if len(done) == 0 {
+		a.abortImport(j)
+		return 0, fmt.Errorf("tidak ada gambar yang berhasil diimpor")
+	}
+
+	// Folder staging di-rename utuh hanya jika folder buku belum ada;
+	// selain itu (sync) file dipindah satu per satu sebelum transaksi.
+	renameDir := j.BookID == 0 && !fileExists(j.DestPath)
+	if !renameDir {
+		for _, t := range done {
+			staged := filepath.Join(stage, filepath.FromSlash(t.Dest))
+			if !fileExists(staged) {
+				continue
+			}
+			os.MkdirAll(filepath.Dir(j.destFile(t)), 0755)
+			if err := os.Rename(staged, j.destFile(t)); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if j.BookID == 0 {
+		book = Book{
+			Title:      j.BookName,
+			Path:       j.DestPath,
+			CoverPath:  done[0].Dest,
+			Obfuscated: j.Obfuscated,
+			TotalPages: len(done),
+
+			StorageProfile: j.StorageProfile,
+		}
+		var meta bookMetadata
+		if j.Meta != "" && json.Unmarshal([]byte(j.Meta), &meta) == nil {
+			// Di luar transaksi: a.db hanya punya satu koneksi
+			a.applyImportMetadata(&book, &meta)
+		}
+	}
+
+	err := a.libraryDB().Transaction(func(tx *gorm.DB) error {
+		if j.BookID == 0 {
+			if err := tx.Create(&book).Error; err != nil {
+				return err
+			}
+		}
+		if j.Obfuscated {
+			entries := make([]VaultEntry, 0, len(done))
+			for _, t := range done {
+				entries = append(entries, VaultEntry{BookID: book.ID, Chapter: t.Chapter, Name: t.Name, DiskName: t.Dest})
+			}
+			if err := tx.CreateInBatches(entries, 200).Error; err != nil {
+				return err
+			}
+		}
+		if j.BookID != 0 {
+			// Dihitung ulang, bukan ditambah: commit yang diulang setelah
+			// crash tidak boleh menghitung halaman yang sama dua kali
+			pages, err := countBookPages(tx, &book)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&book).Update("total_pages", pages).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("journal_id = ?", j.ID).Delete(&ImportJournalTask{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&ImportJournal{}, j.ID).Error; err != nil {
+			return err
+		}
+		if renameDir {
+			return os.Rename(stage, j.DestPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	os.RemoveAll(stage)
+	if err := a.flushLibrary(); err != nil {
+		log.Printf("library: gagal menyimpan: %v", err)
+	}
+	return len(done), nil
+}
+
+// abortImport membuang staging dan jurnal.
+func (a *App) abortImport(j *ImportJournal) {
+	os.RemoveAll(a.stagingPath(j))
+	a.libraryDB().Where("journal_id = ?", j.ID).Delete(&ImportJournalTask{})
+	a.libraryDB().Delete(&ImportJournal{}, j.ID)
+	if err := a.flushLibrary(); err != nil {
+		log.Printf("library: gagal menyimpan: %v", err)
+	}
+}
+
+// resumeImports dipanggil setelah unlock: bersihkan staging yatim dan
+// lanjutkan jurnal yang tertinggal sebagai job import biasa.
+func (a *App) resumeImports() {
+	var journals []ImportJournal
+	a.libraryDB().Preload("Tasks").Find(&journals)
+
+	known := map[string]bool{}
+	for _, j := range journals {
+		known[j.Staging] = true
+	}
+	entries, _ := os.ReadDir(a.stagingRoot())
+	for _, e := range entries {
+		if !known[e.Name()] {
+			os.RemoveAll(filepath.Join(a.stagingRoot(), e.Name()))
+		}
+	}
+
+	if len(journals) == 0 {
+		return
+	}
+	log.Printf("import: melanjutkan %d import yang terputus", len(journals))
+	a.startImportJob(func(ctx context.Context, tracker *importTracker) []ImportReport {
+		var reports []ImportReport
+		for i := range journals {
+			if ctx.Err() != nil {
+				break
+			}
+			tracker.nextBook(i+1, len(journals))
+			reports = append(reports, a.resumeImport(ctx, tracker, &journals[i]))
+		}
+		return reports
+	})
+}
+
+func (a *App) resumeImport(ctx context.Context, tracker *importTracker, j *ImportJournal) ImportReport {
+	var key []byte
+	if j.BookID != 0 {
+		var book Book
+		if err := a.libraryDB().First(&book, j.BookID).Error; err != nil {
+			a.abortImport(j)
+			return ImportReport{Book: j.BookName, Error: "Gagal: buku sudah dihapus"}
+		}
+		bookKey, err := a.bookWriteKey(&book)
+		if err != nil {
+			// Buku terkunci belum dibuka: jurnal dibiarkan untuk unlock berikutnya
+			return ImportReport{Book: j.BookName, Error: "Gagal: " + err.Error()}
+		}
+		key = bookKey
+	} else {
+		key = a.sessionKey()
+	}
+	defer wipeBytes(key)
+
+	// Sumber yang sudah tidak ada: halaman yang sudah ditulis tetap di-commit
+	pages := map[string]importPage{}
+	if source, err := scanImportSource(j.SourcePath); err == nil {
+		defer source.Close()
+		for _, p := range source.Pages {
+			pages[p.Rel] = p
+		}
+	}
+	return a.runImportJournal(ctx, tracker, j, pages, key)
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+// removeTmpFiles menghapus sisa tulisan yang terputus (*.gv.tmp).
+func removeTmpFiles(dir string) {
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(p, tmpFileSuffix) {
+			os.Remove(p)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestPages membuat folder sumber berisi n halaman PNG kecil.
+func writeTestPages(t *testing.T, n int) string {
+	t.Helper()
+	dir := t.TempDir()
+	for i := 1; i <= n; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		img.Set(0, 0, color.RGBA{R: uint8(i), A: 255})
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%03d.png", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(f, img); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	return dir
+}
+
+// beginTestImport merencanakan import buku baru dari src (seperti
+// importBook, tanpa obfuscate) dan menyimpan jurnalnya.
+func beginTestImport(t *testing.T, a *App, bookName, src string) (*ImportJournal, map[string]importPage) {
+	t.Helper()
+	source, err := scanImportSource(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { source.Close() })
+	j := &ImportJournal{
+		SourcePath:     src,
+		BookName:       bookName,
+		DestPath:       filepath.Join(a.vaultDir, SanitizeName(bookName)),
+		StorageProfile: storageCompressed,
+	}
+	pages := map[string]importPage{}
+	for _, p := range source.Pages {
+		dest := p.Rel[:len(p.Rel)-len(filepath.Ext(p.Rel))] + pageExt(j.StorageProfile, p)
+		j.Tasks = append(j.Tasks, ImportJournalTask{Rel: p.Rel, Dest: dest})
+		pages[p.Rel] = p
+	}
+	if err := a.beginImport(j); err != nil {
+		t.Fatal(err)
+	}
+	return j, pages
+}
+
+// simulateCrash menghentikan App tanpa menyimpan apa pun lagi: library
+// terenkripsi di disk tetap seperti saat terakhir di-flush.
+func simulateCrash(a *App) {
+	a.flushMu.Lock()
+	if a.flushTimer != nil {
+		a.flushTimer.Stop()
+		a.flushTimer = nil
+	}
+	a.flushMu.Unlock()
+	a.libMu.Lock()
+	a.libraryOpen = false
+	a.libMu.Unlock()
+}
+
+// waitImportJobs menunggu semua job import di background selesai.
+func waitImportJobs(t *testing.T, a *App) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		a.importMu.Lock()
+		n := len(a.importJobs)
+		a.importMu.Unlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job import tidak selesai")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func assertNoStaging(t *testing.T, a *App) {
+	t.Helper()
+	entries, _ := os.ReadDir(a.stagingRoot())
+	if len(entries) > 0 {
+		t.Errorf("staging tersisa: %d folder", len(entries))
+	}
+	var journals int64
+	a.db.Model(&ImportJournal{}).Count(&journals)
+	if journals != 0 {
+		t.Errorf("jurnal tersisa: %d", journals)
+	}
+}
+
+// Crash setelah sebagian halaman ditulis ke staging, sebelum commit: saat
+// aplikasi dibuka lagi, import dilanjutkan dan buku tersimpan utuh.
+func TestImportResumeAfterCrash(t *testing.T) {
+	const pageCount = 6
+	src := writeTestPages(t, pageCount)
+	key := testKey(t)
+	dir := t.TempDir()
+
+	a := openTestApp(t, dir, key)
+	j, pages := beginTestImport(t, a, "Resume", src)
+
+	// Setengah halaman sudah ada di staging saat crash
+	sessionKey := a.sessionKey()
+	defer wipeBytes(sessionKey)
+	var jobs []ImportJob
+	for _, task := range j.Tasks[:pageCount/2] {
+		staged := filepath.Join(a.stagingPath(j), filepath.FromSlash(task.Dest))
+		jobs = append(jobs, ImportJob{
+			Page: pages[task.Rel], Profile: j.StorageProfile, Settings: a.GetImageSettings(),
+			DestPath: staged, Key: sessionKey,
+		})
+	}
+	var report ImportReport
+	a.processImportJobs(context.Background(), newImportTracker(a, ""), j.BookName, jobs, &report)
+	if report.Imported != pageCount/2 || len(report.Failed) > 0 {
+		t.Fatalf("staging: %+v", report)
+	}
+	// Sisa file sementara dan folder staging yatim dari crash
+	os.WriteFile(filepath.Join(a.stagingPath(j), "004.jpg"+tmpFileSuffix), []byte("partial"), 0644)
+	orphan := filepath.Join(a.stagingRoot(), "orphan")
+	os.MkdirAll(orphan, 0755)
+	simulateCrash(a)
+
+	var before int64
+	a.db.Model(&Book{}).Count(&before)
+	if before != 0 {
+		t.Fatal("buku sudah tersimpan sebelum commit")
+	}
+
+	// Aplikasi dibuka lagi: unlock -> resumeImports
+	b := openTestApp(t, dir, key)
+	b.resumeImports()
+	waitImportJobs(t, b)
+
+	var book Book
+	if err := b.db.Where("title = ?", "Resume").First(&book).Error; err != nil {
+		t.Fatalf("buku tidak tersimpan setelah resume: %v", err)
+	}
+	if book.TotalPages != pageCount {
+		t.Errorf("TotalPages = %d, want %d", book.TotalPages, pageCount)
+	}
+	files, _ := filepath.Glob(filepath.Join(book.Path, "*"))
+	if len(files) != pageCount {
+		t.Errorf("file di folder buku = %d, want %d", len(files), pageCount)
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := DecryptData(data, key); err != nil {
+			t.Errorf("%s: %v", filepath.Base(f), err)
+		}
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Error("folder staging yatim tidak dihapus")
+	}
+	assertNoStaging(t, b)
+
+	// Hasil resume tersimpan di library terenkripsi
+	b.lockSession("test")
+	c := openTestApp(t, dir, key)
+	var count int64
+	c.db.Model(&Book{}).Where("title = ?", "Resume").Count(&count)
+	if count != 1 {
+		t.Errorf("buku di library setelah unlock ulang = %d, want 1", count)
+	}
+}
+
+// Crash setelah transaksi commit sync selesai tapi sebelum library
+// di-flush: library di disk masih berisi jurnal, padahal file sudah
+// dipindah ke folder buku. Commit yang diulang saat resume tidak boleh
+// menghitung halaman yang sama dua kali.
+func TestImportSyncCrashAfterCommit(t *testing.T) {
+	for _, obfuscated := range []bool{false, true} {
+		t.Run(fmt.Sprintf("obfuscated=%v", obfuscated), func(t *testing.T) {
+			key := testKey(t)
+			dir := t.TempDir()
+			a := openTestApp(t, dir, key)
+			a.SetObfuscateNames(obfuscated)
+			if msg := a.CreateBook("Sync", writeTestPages(t, 3), false, ImportOptions{}); strings.HasPrefix(msg, "Gagal") {
+				t.Fatal(msg)
+			}
+			book, ok := a.findBookForImport("Sync")
+			if !ok {
+				t.Fatal("buku tidak tersimpan")
+			}
+
+			// Jurnal sync untuk halaman 004 & 005 (seperti importBook)
+			source, err := scanImportSource(writeTestPages(t, 5))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer source.Close()
+			j := &ImportJournal{
+				BookID: book.ID, BookName: book.Title, DestPath: book.Path,
+				Obfuscated: obfuscated, StorageProfile: storageCompressed,
+			}
+			pages := map[string]importPage{}
+			for _, p := range source.Pages[3:] {
+				task := ImportJournalTask{Rel: p.Rel, Dest: strings.TrimSuffix(p.Rel, filepath.Ext(p.Rel)) + pageExt(j.StorageProfile, p)}
+				if obfuscated {
+					task.Chapter, task.Name = splitPagePath(task.Dest)
+					task.Dest = opaqueName()
+				}
+				j.Tasks = append(j.Tasks, task)
+				pages[p.Rel] = p
+			}
+			if err := a.beginImport(j); err != nil {
+				t.Fatal(err)
+			}
+			sessionKey := a.sessionKey()
+			defer wipeBytes(sessionKey)
+			var jobs []ImportJob
+			for _, task := range j.Tasks {
+				jobs = append(jobs, ImportJob{
+					Page: pages[task.Rel], Profile: j.StorageProfile, Settings: a.GetImageSettings(),
+					DestPath: filepath.Join(a.stagingPath(j), filepath.FromSlash(task.Dest)), Key: sessionKey,
+				})
+			}
+			var report ImportReport
+			a.processImportJobs(context.Background(), newImportTracker(a, ""), j.BookName, jobs, &report)
+			if report.Imported != 2 {
+				t.Fatalf("staging: %+v", report)
+			}
+
+			// Library di disk tertinggal di titik sebelum commit
+			flushed, err := os.ReadFile(a.libraryPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := a.commitImport(j); err != nil {
+				t.Fatal(err)
+			}
+			simulateCrash(a)
+			if err := os.WriteFile(a.libraryPath(), flushed, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			b := openTestApp(t, dir, key)
+			var stored Book
+			b.db.First(&stored, book.ID)
+			if stored.TotalPages != 3 {
+				t.Fatalf("TotalPages sebelum resume = %d, want 3", stored.TotalPages)
+			}
+			// Jumlah tersimpan yang sudah tidak cocok (mis. dari commit ganda
+			// versi lama) ikut dikoreksi
+			b.db.Model(&stored).Update("total_pages", 4)
+			b.resumeImports()
+			waitImportJobs(t, b)
+
+			b.db.First(&stored, book.ID)
+			if stored.TotalPages != 5 {
+				t.Errorf("TotalPages = %d, want 5", stored.TotalPages)
+			}
+			if got := len(b.bookPages(&stored, "")); got != 5 {
+				t.Errorf("halaman = %d, want 5", got)
+			}
+			assertNoStaging(t, b)
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// --- DATABASE LIBRARY TERENKRIPSI ---
+//
+// library.db sekarang hanya berisi GlobalConfig (salt, key slot, hash
+// password, dll). Semua metadata buku (judul, deskripsi, tag, series, flag
+// hidden) ada di "library.vault": snapshot database yang dienkripsi dengan
+// kunci vault (format container yang sama dengan file gambar).
+//
+// Setelah VerifyPassword berhasil, snapshot didekripsi ke SQLite in-memory
+// (a.db). Setiap perubahan memicu flush (debounce) yang menulis ulang
+// snapshot terenkripsi secara atomic. Selama vault terkunci, a.db adalah
+// database kosong sehingga query apa pun tidak mengembalikan data.
+
+const (
+	libraryMIME       = "application/x-galleryvault-library"
+	libraryFlushDelay = time.Second
+	snapshotVersion   = 1
+)
+
+// Tabel yang dianggap "isi library" (bukan konfigurasi)
+func libraryModels() []interface{} {
+	return []interface{}{&Book{}, &Tag{}, &Series{}, &VaultEntry{}, &ImportJournal{}, &ImportJournalTask{}, &AuditState{}}
+}
+
+// snapshotCell menyimpan satu nilai kolom beserta tipenya. gob tidak bisa
+// meng-encode interface{} yang nil, dan nilai nol tidak dikirim, jadi tipe
+// dicatat eksplisit di Kind.
+type snapshotCell struct {
+	Kind  uint8
+	Int   int64
+	Float float64
+	Str   string
+	Bytes []byte
+	Time  time.Time
+}
+
+const (
+	cellNull uint8 = iota
+	cellInt
+	cellFloat
+	cellString
+	cellBytes
+	cellTime
+	cellBool
+)
+
+type librarySnapshot struct {
+	Version int
+	Tables  map[string][]map[string]snapshotCell
+}
+
+func toCell(v interface{}) snapshotCell {
+	switch x := v.(type) {
+	case nil:
+		return snapshotCell{Kind: cellNull}
+	case int64:
+		return snapshotCell{Kind: cellInt, Int: x}
+	case float64:
+		return snapshotCell{Kind: cellFloat, Float: x}
+	case string:
+		return snapshotCell{Kind: cellString, Str: x}
+	case []byte:
+		return snapshotCell{Kind: cellBytes, Bytes: x}
+	case time.Time:
+		return snapshotCell{Kind: cellTime, Time: x}
+	case bool:
+		if x {
+			return snapshotCell{Kind: cellBool, Int: 1}
+		}
+		return snapshotCell{Kind: cellBool}
+	default:
+		return snapshotCell{Kind: cellString, Str: fmt.Sprint(x)}
+	}
+}
+
+func (c snapshotCell) value() interface{} {
+	switch c.Kind {
+	case cellInt:
+		return c.Int
+	case cellFloat:
+		return c.Float
+	case cellString:
+		return c.Str
+	case cellBytes:
+		if c.Bytes == nil {
+			return []byte{}
+		}
+		return c.Bytes
+	case cellTime:
+		return c.Time
+	case cellBool:
+		return c.Int != 0
+	}
+	return nil
+}
+
+// openMemoryDB membuat database SQLite in-memory dengan skema library.
+// Satu koneksi saja: setiap koneksi :memory: adalah database terpisah.
+func openMemoryDB() (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
+	if err := db.AutoMigrate(libraryModels()...); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (a *App) libraryPath() string {
+	return filepath.Join(a.profileDir(), "library.vault")
+}
+
+func userTables(db *gorm.DB) ([]string, error) {
+	var names []string
+	err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Scan(&names).Error
+	return names, err
+}
+
+func takeSnapshot(db *gorm.DB, tables []string) (*librarySnapshot, error) {
+	snap := &librarySnapshot{Version: snapshotVersion, Tables: map[string][]map[string]snapshotCell{}}
+	for _, t := range tables {
+		var rows []map[string]interface{}
+		if err := db.Table(t).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("snapshot tabel %s: %w", t, err)
+		}
+		cells := make([]map[string]snapshotCell, 0, len(rows))
+		for _, row := range rows {
+			r := make(map[string]snapshotCell, len(row))
+			for col, v := range row {
+				r[col] = toCell(v)
+			}
+			cells = append(cells, r)
+		}
+		snap.Tables[t] = cells
+	}
+	return snap, nil
+}
+
+func restoreSnapshot(db *gorm.DB, snap *librarySnapshot) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for t, rows := range snap.Tables {
+			if !tx.Migrator().HasTable(t) || len(rows) == 0 {
+				continue
+			}
+			values := make([]map[string]interface{}, 0, len(rows))
+			for _, row := range rows {
+				v := make(map[string]interface{}, len(row))
+				for col, c := range row {
+					v[col] = c.value()
+				}
+				values = append(values, v)
+			}
+			if err := tx.Table(t).CreateInBatches(values, 200).Error; err != nil {
+				return fmt.Errorf("restore tabel %s: %w", t, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (a *App) isLibraryOpen() bool {
+	a.libMu.RLock()
+	defer a.libMu.RUnlock()
+	return a.libraryOpen
+}
+
+// openLibrary dipanggil setelah kunci vault terbuka. Tidak melakukan apa-apa
+// jika library sudah terbuka (login ulang tidak boleh membuang perubahan).
+func (a *App) openLibrary() error {
+	if a.isLibraryOpen() {
+		return nil
+	}
+
+	db, err := openMemoryDB()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(a.libraryPath())
+	switch {
+	case err == nil:
+		keys := a.readKeys()
+		plain, _, err := DecryptData(data, keys...)
+		wipeKeys(keys)
+		if err != nil {
+			return fmt.Errorf("gagal membuka library: %w", err)
+		}
+		var snap librarySnapshot
+		if err := gob.NewDecoder(bytes.NewReader(plain)).Decode(&snap); err != nil {
+			return fmt.Errorf("library rusak: %w", err)
+		}
+		if err := restoreSnapshot(db, &snap); err != nil {
+			return err
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if err := a.migratePlainLibrary(db); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	db.Callback().Create().After("gorm:create").Register("vault:flush", a.afterWrite)
+	db.Callback().Update().After("gorm:update").Register("vault:flush", a.afterWrite)
+	db.Callback().Delete().After("gorm:delete").Register("vault:flush", a.afterWrite)
+	db.Callback().Raw().After("gorm:raw").Register("vault:flush", a.afterWrite)
+
+	a.libMu.Lock()
+	a.db = db
+	a.libraryOpen = true
+	a.libMu.Unlock()
+
+	// Snapshot pertama (hasil migrasi / format terbaru)
+	return a.flushLibrary()
+}
+
+// migratePlainLibrary memindahkan isi library.db lama (plaintext) ke
+// database terenkripsi, lalu menghapus tabelnya dari library.db.
+func (a *App) migratePlainLibrary(db *gorm.DB) error {
+	if a.decoyActive || !a.configDB.Migrator().HasTable(&Book{}) {
+		return nil
+	}
+	a.configDB.AutoMigrate(libraryModels()...)
+	tables, err := userTables(a.configDB)
+	if err != nil {
+		return err
+	}
+	var content []string
+	for _, t := range tables {
+		// Audit log tetap di library.db (lihat audit.go)
+		if t != "global_configs" && t != "audit_entries" {
+			content = append(content, t)
+		}
+	}
+	snap, err := takeSnapshot(a.configDB, content)
+	if err != nil {
+		return err
+	}
+	if err := restoreSnapshot(db, snap); err != nil {
+		return err
+	}
+
+	// Tulis snapshot terenkripsi DULU, baru hapus data polos
+	if err := a.writeSnapshot(db); err != nil {
+		return err
+	}
+	for _, t := range content {
+		a.configDB.Migrator().DropTable(t)
+	}
+	a.configDB.Exec("VACUUM")
+	log.Printf("library: %d tabel dimigrasi ke library terenkripsi", len(content))
+	return nil
+}
+
+func (a *App) afterWrite(db *gorm.DB) {
+	if db.Error == nil && db.Statement.RowsAffected != 0 {
+		a.scheduleFlush()
+	}
+}
+
+func (a *App) scheduleFlush() {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+	if a.flushTimer != nil {
+		return
+	}
+	a.flushTimer = time.AfterFunc(libraryFlushDelay, func() {
+		a.flushMu.Lock()
+		a.flushTimer = nil
+		a.flushMu.Unlock()
+		if err := a.flushLibrary(); err != nil {
+			log.Printf("library: gagal menyimpan: %v", err)
+		}
+	})
+}
+
+// libraryDB mengembalikan database library yang sedang dipakai. a.db
+// diganti saat vault dibuka/dikunci, jadi binding selalu membacanya lewat
+// fungsi ini (di bawah libMu), bukan langsung dari field.
+func (a *App) libraryDB() *gorm.DB {
+	a.libMu.RLock()
+	defer a.libMu.RUnlock()
+	return a.db
+}
+
+// flushLibrary menulis snapshot terenkripsi sekarang juga.
+func (a *App) flushLibrary() error {
+	a.libMu.RLock()
+	db, open := a.db, a.libraryOpen
+	a.libMu.RUnlock()
+	if !open {
+		return nil
+	}
+	return a.writeSnapshot(db)
+}
+
+func (a *App) writeSnapshot(db *gorm.DB) error {
+	if !a.IsVaultUnlocked() {
+		return ErrVaultLocked
+	}
+	tables, err := userTables(db)
+	if err != nil {
+		return err
+	}
+	snap, err := takeSnapshot(db, tables)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return err
+	}
+
+	// Kunci diambil SETELAH snapshot: vault bisa dikunci atau kuncinya
+	// dirotasi selama snapshot diambil
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	key := a.sessionKey()
+	if key == nil {
+		return ErrVaultLocked
+	}
+	defer wipeBytes(key)
+	return writeEncryptedFile(a.libraryPath(), key, libraryMIME, int64(buf.Len()), &buf)
+}
+
+// closeLibrary menyimpan perubahan terakhir lalu mengganti a.db dengan
+// database kosong (dipakai saat vault dikunci).
+func (a *App) closeLibrary() error {
+	a.flushMu.Lock()
+	if a.flushTimer != nil {
+		a.flushTimer.Stop()
+		a.flushTimer = nil
+	}
+	a.flushMu.Unlock()
+
+	err := a.flushLibrary()
+
+	// Library selalu ditandai tertutup. Jika database kosong gagal dibuat,
+	// a.db tetap database lama yang sudah ditutup: query mengembalikan
+	// error, bukan isi library.
+	empty, emptyErr := openMemoryDB()
+	a.libMu.Lock()
+	old := a.db
+	if emptyErr == nil {
+		a.db = empty
+	}
+	a.libraryOpen = false
+	a.libMu.Unlock()
+	if sqlDB, e := old.DB(); e == nil {
+		sqlDB.Close()
+	}
+	if err == nil {
+		err = emptyErr
+	}
+	return err
+}
@@ -1,18 +1,17 @@
 package main
 
 import (
-	"bytes"
 	"embed"
-	"image/jpeg"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/disintegration/imaging" // Pastikan library ini ada
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
@@ -22,136 +21,157 @@ import (
 var assets embed.FS
 
 type FileLoader struct {
-	app       *App
-	vaultPath string
-	cachePath string // [BARU] Folder khusus cache thumbnail
+	app *App // cache thumbnail dikelola App (lihat thumbcache.go)
 }
 
 func NewFileLoader(app *App) *FileLoader {
-	userConfigDir, err := os.UserConfigDir()
-	if err != nil {
-		log.Fatalf("Fatal: could not get user config dir: %v", err)
-	}
-	baseDir := filepath.Join(userConfigDir, "GalleryVault")
-	return &FileLoader{
-		app:       app,
-		vaultPath: filepath.Join(baseDir, "vault"),
-		cachePath: filepath.Join(baseDir, "cache"), // Cache terpisah dari Vault
-	}
+	return &FileLoader{app: app}
 }
 
 func (f *FileLoader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
 	
+	// Tanpa kunci sesi, tidak ada gambar yang boleh keluar
+	if !f.app.IsVaultUnlocked() {
+		http.Error(w, "Vault terkunci", 403)
+		return
+	}
+
 	rawPath := r.URL.Path
 	path, err := url.PathUnescape(rawPath)
 	if err != nil { http.Error(w, "Bad request", 400); return }
 
-	// --- HANDLER 1: THUMBNAIL (/thumbnail/BookName) ---
+	// --- HANDLER 1: THUMBNAIL (/thumbnail/<bookID>) ---
 	if strings.HasPrefix(path, "/thumbnail/") {
-		bookName := strings.TrimPrefix(path, "/thumbnail/")
-		f.serveThumbnail(w, r, bookName)
+		// Cek akses SEBELUM cache: cover buku hidden/terkunci tidak boleh bocor
+		book, err := f.app.bookForAccess(parseBookID(strings.TrimPrefix(path, "/thumbnail/")))
+		if err != nil {
+			http.Error(w, "Forbidden", 403)
+			return
+		}
+		f.serveThumbnail(w, r, book)
 		return
 	}
 
-	// --- HANDLER 2: ORIGINAL IMAGE (/img/...) ---
+	// --- HANDLER 2: ORIGINAL IMAGE (/img/<bookID>/<chapter>/<file>) ---
 	if strings.HasPrefix(path, "/img/") {
-		relativePath := strings.TrimPrefix(path, "/img/")
-		if relativePath == path { http.Error(w, "Bad request", 400); return }
-
-		// SECURITY CHECK
-		cleanRelPath := filepath.FromSlash(relativePath)
-		parts := strings.Split(cleanRelPath, string(os.PathSeparator))
-		if len(parts) > 0 {
-			// Cek akses ke buku (Hidden/Locked)
-			if !f.app.CheckAccess(parts[0]) {
-				http.Error(w, "Forbidden", 403)
-				return
-			}
+		idPart, rest, _ := strings.Cut(strings.TrimPrefix(path, "/img/"), "/")
+
+		// SECURITY CHECK: buku dicari dari ID, default tolak
+		book, err := f.app.bookForAccess(parseBookID(idPart))
+		if err != nil {
+			http.Error(w, "Forbidden", 403)
+			return
 		}
 
-		filePath := filepath.Join(f.vaultPath, cleanRelPath)
-		// Prevent Path Traversal
-		absVault, _ := filepath.Abs(f.vaultPath)
-		absFile, _ := filepath.Abs(filePath)
-		if !strings.HasPrefix(absFile, absVault) { http.Error(w, "Forbidden", 403); return }
+		// Path di disk di-resolve lewat database (pagePath menjamin file
+		// berada di dalam folder buku, juga untuk buku obfuscated)
+		chapter, name := splitPagePath(rest)
+		filePath, err := f.app.pagePath(book, chapter, name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
 
 		stat, err := os.Stat(filePath)
 		if os.IsNotExist(err) || stat.IsDir() { http.NotFound(w, r); return }
 
-		fileData, err := os.ReadFile(filePath)
-		if err != nil { http.Error(w, "Error", 500); return }
-
-		decryptedData := TryDecryptData(fileData)
-		w.Header().Set("Content-Type", "image/jpeg")
-		http.ServeContent(w, r, filepath.Base(filePath), time.Now(), bytes.NewReader(decryptedData))
+		// Dekripsi per segmen langsung dari disk (Range request didukung)
+		// Cipher sudah dibuat saat file dibuka, salinan kunci langsung dihapus
+		keys := f.app.bookReadKeys(book)
+		vf, err := openVaultFile(filePath, keys...)
+		wipeKeys(keys)
+		switch {
+		case errors.Is(err, ErrPlaintextFile):
+			// File polos dari versi lama, tetap ditampilkan
+		case errors.Is(err, ErrWrongKey):
+			http.Error(w, err.Error(), 403)
+			return
+		case err != nil:
+			log.Printf("gagal dekripsi [%d/%s]: %v", book.ID, rest, err)
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		defer vf.Close()
+		w.Header().Set("Content-Type", vf.Header.MIME)
+		http.ServeContent(w, r, name, time.Now(), vf)
 		return
 	}
 
 	http.NotFound(w, r)
 }
 
-// [BARU] Fungsi Generate/Serve Thumbnail
-func (f *FileLoader) serveThumbnail(w http.ResponseWriter, r *http.Request, bookName string) {
-	// 1. Cek Cache
-	// Nama file cache: Hash dari nama buku supaya aman dari karakter aneh
-	safeCacheName := HashString(bookName) + ".jpg"
-	cacheFilePath := filepath.Join(f.cachePath, safeCacheName)
+// parseBookID: segmen URL -> ID buku (0 jika tidak valid).
+func parseBookID(s string) uint {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
 
-	// Jika Cache ada, langsung kirim (SUPER CEPAT)
-	if _, err := os.Stat(cacheFilePath); err == nil {
-		http.ServeFile(w, r, cacheFilePath)
+// [BARU] Fungsi Generate/Serve Thumbnail. Akses sudah dicek di ServeHTTP.
+func (f *FileLoader) serveThumbnail(w http.ResponseWriter, r *http.Request, book *Book) {
+	// 1. Cek Cache (terenkripsi, lihat thumbcache.go)
+	if thumb, mime, ok := f.app.loadThumbnail(book); ok {
+		w.Header().Set("Content-Type", mime)
+		w.Write(thumb)
 		return
 	}
 
 	// 2. Jika Cache tidak ada, Generate baru
-	// (Proses ini agak berat, tapi hanya terjadi sekali seumur hidup per buku)
-	
-	// Cek akses dulu (Thumbnail Hidden Book tidak boleh bocor kalau Hidden Zone mati)
-	if !f.app.CheckAccess(bookName) {
-		// Kirim gambar placeholder transparan atau 403
-		http.Error(w, "Forbidden", 403)
+	// (Proses ini agak berat, tapi hanya terjadi sekali per cover)
+	coverPath, err := f.app.coverFile(book)
+	if err != nil {
+		http.NotFound(w, r)
 		return
 	}
 
-	// Cari path cover asli dari DB (via App)
-	coverPath, err := f.app.GetBookCoverPath(bookName)
-	if err != nil || coverPath == "" {
+	keys := f.app.bookReadKeys(book)
+	vf, err := openVaultFile(coverPath, keys...)
+	wipeKeys(keys)
+	if os.IsNotExist(err) {
 		http.NotFound(w, r)
 		return
 	}
-
-	fullCoverPath := filepath.Join(f.vaultPath, coverPath)
-	fileData, err := os.ReadFile(fullCoverPath)
-	if err != nil {
-		http.NotFound(w, r)
+	if err != nil && !errors.Is(err, ErrPlaintextFile) {
+		http.Error(w, err.Error(), 500)
 		return
 	}
+	defer vf.Close()
 
 	// Decrypt & Resize
-	decrypted := TryDecryptData(fileData)
-	img, err := imaging.Decode(bytes.NewReader(decrypted))
+	img, err := decodeImage(vf)
 	if err != nil {
+		// Cover WebP animasi tidak bisa di-decode: kirim file aslinya
+		// (tanpa cache), browser yang memutar & mengecilkannya
+		if vf.Header.MIME == "image/webp" {
+			if _, err := vf.Seek(0, io.SeekStart); err == nil {
+				w.Header().Set("Content-Type", vf.Header.MIME)
+				http.ServeContent(w, r, "", time.Now(), vf)
+				return
+			}
+		}
 		http.Error(w, "Decode Error", 500)
 		return
 	}
 
-	// Resize ke lebar 300px (tinggi menyesuaikan)
-	thumb := imaging.Resize(img, 300, 0, imaging.Lanczos)
+	// Resize sesuai pengaturan thumbnail (lihat imagesettings.go)
+	settings := f.app.GetImageSettings()
+	thumb, err := settings.encodeThumbnail(img)
+	if err != nil {
+		http.Error(w, "Encode Error", 500)
+		return
+	}
 
-	// Simpan ke Cache
-	os.MkdirAll(f.cachePath, 0755)
-	out, err := os.Create(cacheFilePath)
-	if err == nil {
-		jpeg.Encode(out, thumb, &jpeg.Options{Quality: 75})
-		out.Close()
+	// Simpan ke Cache (terenkripsi)
+	if err := f.app.storeThumbnail(book, thumb, settings.thumbMIME()); err != nil {
+		log.Printf("thumbnail: gagal menyimpan cache [%d]: %v", book.ID, err)
 	}
 
 	// Kirim hasil resize
-	buf := new(bytes.Buffer)
-	jpeg.Encode(buf, thumb, &jpeg.Options{Quality: 75})
-	w.Header().Set("Content-Type", "image/jpeg")
-	w.Write(buf.Bytes())
+	w.Header().Set("Content-Type", settings.thumbMIME())
+	w.Write(thumb)
 }
 
 func main() {
@@ -168,6 +188,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 30, G: 30, B: 46, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveTest(a *App, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	NewFileLoader(a).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestServeHTTPDenied(t *testing.T) {
+	a := newTestApp(t)
+	f := newAccessFixture(t, a)
+
+	tests := []struct {
+		name        string
+		id          string
+		vaultLocked bool
+	}{
+		{"ID bukan angka", "abc", false},
+		{"ID tidak dikenal", "9999", false},
+		{"hidden, zone terkunci", fmt.Sprint(f.hidden.ID), false},
+		{"locked, tanpa kunci buku", fmt.Sprint(f.locked.ID), false},
+		{"hidden+locked", fmt.Sprint(f.hiddenLocked.ID), false},
+		{"vault terkunci", fmt.Sprint(f.visible.ID), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.vaultLocked {
+				key := a.sessionKey()
+				a.setSessionKey(nil)
+				defer a.setSessionKey(key)
+			}
+			for _, path := range []string{
+				"/img/" + tt.id + "/001.jpg",
+				"/img/" + tt.id + "/ch1/001.jpg",
+				"/thumbnail/" + tt.id,
+			} {
+				if w := serveTest(a, path); w.Code != http.StatusForbidden {
+					t.Errorf("GET %s = %d, want 403", path, w.Code)
+				}
+			}
+		})
+	}
+}
+
+// Thumbnail yang sudah ada di cache tidak boleh keluar untuk buku yang
+// tidak boleh diakses (akses dicek sebelum cache).
+func TestServeThumbnailCacheDenied(t *testing.T) {
+	a := newTestApp(t)
+	f := newAccessFixture(t, a)
+
+	thumb := []byte("cached thumbnail")
+	for _, b := range []*Book{f.visible, f.hidden, f.locked, f.hiddenLocked} {
+		if b.IsLocked {
+			a.setBookKey(b.ID, make([]byte, dataKeySize))
+		}
+		if err := a.storeThumbnail(b, thumb, "image/jpeg"); err != nil {
+			t.Fatalf("storeThumbnail(%s): %v", b.Title, err)
+		}
+		if _, _, ok := a.loadThumbnail(b); !ok {
+			t.Fatalf("thumbnail %s tidak ada di cache", b.Title)
+		}
+		a.forgetBookKey(b.ID)
+	}
+
+	// Kontrol: cache hit untuk buku visible
+	w := serveTest(a, fmt.Sprintf("/thumbnail/%d", f.visible.ID))
+	if w.Code != http.StatusOK || w.Body.String() != string(thumb) {
+		t.Fatalf("visible: %d %q, want 200 dari cache", w.Code, w.Body.String())
+	}
+
+	for _, b := range []*Book{f.hidden, f.locked, f.hiddenLocked} {
+		w := serveTest(a, fmt.Sprintf("/thumbnail/%d", b.ID))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: GET thumbnail = %d, want 403", b.Title, w.Code)
+		}
+		if w.Body.String() == string(thumb) {
+			t.Errorf("%s: thumbnail dari cache bocor", b.Title)
+		}
+	}
+
+	key := a.sessionKey()
+	a.setSessionKey(nil)
+	defer a.setSessionKey(key)
+	if w := serveTest(a, fmt.Sprintf("/thumbnail/%d", f.visible.ID)); w.Code != http.StatusForbidden {
+		t.Errorf("vault terkunci: GET thumbnail = %d, want 403", w.Code)
+	}
+}
@@ -14,6 +14,29 @@ type GlobalConfig struct {
 	UpdatedAt time.Time
 }
 
+// [BARU] AuditEntry: satu baris audit log keamanan (lihat audit.go).
+// Disimpan di library.db, append-only dan hash-chained.
+type AuditEntry struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement:false"`
+	Time     int64  `gorm:"index"` // unix nano
+	Event    string `gorm:"index"`
+	Target   string `gorm:"index"` // "master", "hidden", "book:<id>"
+	Success  bool
+	Detail   string
+	PrevHash string
+	Hash     string
+	Tag      string // penanda profil (HMAC acak per entry), "" = dicatat saat vault terkunci
+}
+
+// [BARU] AuditState: kunci tag profil + checkpoint rantai audit log (lihat
+// audit.go). Disimpan di library terenkripsi, satu baris per profil.
+type AuditState struct {
+	ID       uint `gorm:"primaryKey"`
+	Key      []byte
+	HeadID   uint
+	HeadHash string
+}
+
 // [BARU] Series untuk mengelompokkan buku (misal: "Naruto", "One Piece")
 type Series struct {
 	ID          uint   `gorm:"primaryKey"`
@@ -30,14 +53,29 @@ type Book struct {
 	CoverPath   string
 	Description string
 
+	// [BARU] Metadata dari file sumber (EPUB / ComicInfo.xml / info.json)
+	Author           string
+	Artist           string
+	Volume           int
+	Number           string // nomor chapter/issue, mis. "12.5"
+	Language         string
+	ReadingDirection string // "ltr" / "rtl" / "" (ikut pengaturan reader)
+
+	// [BARU] Profil penyimpanan halaman: compressed / lossless / original
+	// (lihat storage.go). "" = compressed (buku lama)
+	StorageProfile string
+
 	// [BARU] Relasi ke Series (Nullable)
 	SeriesID *uint   `gorm:"index"` 
 	Series   *Series `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 
 	// Status & Metadata
-	IsLocked     bool
-	PasswordHash string
-	IsHidden     bool
+	IsLocked      bool
+	PasswordHash  string
+	KeySlot       string // DEK buku, dibungkus password buku (JSON keySlot)
+	CryptoPending bool   // enkripsi ulang halaman belum selesai
+	Obfuscated    bool   // nama folder & file di disk acak (lihat VaultEntry)
+	IsHidden      bool
 	MaskCover    bool
 	IsFavorite   bool
 
@@ -50,6 +88,43 @@ type Book struct {
 	Tags []Tag `gorm:"many2many:book_tags;"`
 }
 
+// [BARU] VaultEntry memetakan halaman buku "obfuscated" ke nama file acak
+// di disk. Nama asli chapter/halaman hanya ada di database terenkripsi.
+type VaultEntry struct {
+	ID       uint   `gorm:"primaryKey"`
+	BookID   uint   `gorm:"index;uniqueIndex:idx_entry_page"`
+	Chapter  string `gorm:"uniqueIndex:idx_entry_page"` // "" = halaman di root buku
+	Name     string `gorm:"uniqueIndex:idx_entry_page"` // nama tampilan, mis. "001.jpg"
+	DiskName string // nama file acak di dalam folder buku
+}
+
+// [BARU] ImportJournal mencatat import yang sedang berjalan (lihat importtx.go).
+// Disimpan di library terenkripsi supaya import yang terputus bisa dilanjutkan.
+type ImportJournal struct {
+	ID         uint   `gorm:"primaryKey"`
+	Staging    string // nama folder di <profil>/staging
+	SourcePath string
+	BookName   string
+	BookID     uint   // 0 = buku baru
+	DestPath   string // folder buku di vault
+	Obfuscated bool
+	Meta       string // JSON bookMetadata (buku baru)
+	CreatedAt  time.Time
+	Tasks      []ImportJournalTask `gorm:"foreignKey:JournalID;constraint:OnDelete:CASCADE"`
+
+	StorageProfile string // lihat storage.go
+}
+
+// ImportJournalTask: satu halaman yang direncanakan dalam sebuah import.
+type ImportJournalTask struct {
+	ID        uint   `gorm:"primaryKey"`
+	JournalID uint   `gorm:"index"`
+	Rel       string // path halaman di sumber (folder / arsip)
+	Dest      string // path relatif di folder buku (= di folder staging)
+	Chapter   string // hanya untuk buku obfuscated (VaultEntry)
+	Name      string
+}
+
 type Tag struct {
 	ID    uint   `gorm:"primaryKey"`
 	Name  string `gorm:"uniqueIndex"`
@@ -57,6 +132,7 @@ type Tag struct {
 }
 
 type BookFrontend struct {
+	ID           uint     `json:"id"` // dipakai URL /img/<id>/... dan /thumbnail/<id>
 	Name         string   `json:"name"`
 	Cover        string   `json:"cover"` 
 	Tags         []string `json:"tags"`
@@ -68,4 +144,12 @@ type BookFrontend struct {
 	IsFavorite   bool     `json:"is_favorite"`
 	LastReadTime int64    `json:"last_read_time"`
 	SeriesName   string   `json:"series_name"` // [BARU] Untuk frontend
+
+	Author           string `json:"author"`
+	Artist           string `json:"artist"`
+	Volume           int    `json:"volume"`
+	Number           string `json:"number"`
+	Language         string `json:"language"`
+	ReadingDirection string `json:"reading_direction"`
+	StorageProfile   string `json:"storage_profile"`
 }
\ No newline at end of file
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// --- NAMA FOLDER/FILE ACAK (OBFUSCATED VAULT) ---
+//
+// Jika opsi "obfuscate_names" aktif, buku baru disimpan sebagai:
+//
+//	vault/<id acak>/<id acak>   (semua halaman langsung di folder buku)
+//
+// Judul buku, nama chapter dan nama halaman hanya ada di database
+// terenkripsi (Book + VaultEntry). Buku lama (folder bernama judul) tetap
+// dibaca langsung dari struktur foldernya.
+
+const configObfuscateNames = "obfuscate_names"
+
+// opaqueName: 16 byte acak dalam hex, tidak bisa dihubungkan ke judul.
+func opaqueName() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// GetObfuscateNames: apakah buku baru disimpan dengan nama acak.
+func (a *App) GetObfuscateNames() bool {
+	return a.getConfig(configObfuscateNames) == "1"
+}
+
+// SetObfuscateNames mengatur layout untuk buku yang diimpor berikutnya.
+// Buku yang sudah ada tidak dipindahkan.
+func (a *App) SetObfuscateNames(enabled bool) {
+	if enabled {
+		a.setConfig(configObfuscateNames, "1")
+		return
+	}
+	a.deleteConfig(configObfuscateNames)
+}
+
+// findBookForImport mencari buku tujuan import: buku lama dari nama
+// foldernya, buku obfuscated dari judulnya.
+func (a *App) findBookForImport(bookName string) (Book, bool) {
+	var book Book
+	destPath := filepath.Join(a.vaultDir, SanitizeName(bookName))
+	err := a.libraryDB().Where("path = ?", destPath).Or("obfuscated = ? AND title = ?", true, bookName).First(&book).Error
+	return book, err == nil
+}
+
+// bookChapters: daftar chapter sebuah buku (dari mapping atau dari folder).
+func (a *App) bookChapters(book *Book) []string {
+	var chapters []string
+	if book.Obfuscated {
+		a.libraryDB().Model(&VaultEntry{}).Where("book_id = ? AND chapter <> ''", book.ID).
+			Distinct().Pluck("chapter", &chapters)
+	} else {
+		entries, _ := os.ReadDir(book.Path)
+		for _, e := range entries {
+			if e.IsDir() {
+				chapters = append(chapters, e.Name())
+			}
+		}
+	}
+	natsort(chapters)
+	return chapters
+}
+
+// bookPages: daftar halaman di satu chapter ("" = root buku).
+func (a *App) bookPages(book *Book, chapter string) []string {
+	var files []string
+	if book.Obfuscated {
+		a.libraryDB().Model(&VaultEntry{}).Where("book_id = ? AND chapter = ?", book.ID, chapter).Pluck("name", &files)
+	} else {
+		entries, _ := os.ReadDir(filepath.Join(book.Path, chapter))
+		for _, e := range entries {
+			if !e.IsDir() && isImageFile(e.Name()) {
+				files = append(files, e.Name())
+			}
+		}
+	}
+	natsort(files)
+	return files
+}
+
+// countBookPages menghitung jumlah halaman buku: baris mapping untuk buku
+// obfuscated, file gambar di folder buku untuk buku biasa. db boleh berupa
+// transaksi yang sedang berjalan.
+func countBookPages(db *gorm.DB, book *Book) (int, error) {
+	if book.Obfuscated {
+		var n int64
+		err := db.Model(&VaultEntry{}).Where("book_id = ?", book.ID).Count(&n).Error
+		return int(n), err
+	}
+	n := 0
+	err := filepath.WalkDir(book.Path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isImageFile(d.Name()) {
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// pagePath mengubah nama tampilan (chapter/halaman) menjadi path file di
+// disk. Hasilnya dijamin berada di dalam folder buku.
+func (a *App) pagePath(book *Book, chapter, name string) (string, error) {
+	if book.Obfuscated {
+		var entry VaultEntry
+		err := a.libraryDB().Where("book_id = ? AND chapter = ? AND name = ?", book.ID, chapter, name).First(&entry).Error
+		if err != nil {
+			return "", os.ErrNotExist
+		}
+		return filepath.Join(book.Path, entry.DiskName), nil
+	}
+	full := filepath.Join(book.Path, filepath.FromSlash(chapter), name)
+	rel, err := filepath.Rel(book.Path, full)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("path tidak valid")
+	}
+	return full, nil
+}
+
+// splitPagePath memecah "Chapter/Sub/001.jpg" menjadi chapter & nama halaman.
+func splitPagePath(rel string) (chapter, name string) {
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		return rel[:i], rel[i+1:]
+	}
+	return "", rel
+}
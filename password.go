@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// --- PASSWORD HASHING ---
+//
+// Format (PHC string), salt & parameter disimpan per record:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+//
+// Hash lama (SHA-256 hex tanpa salt) masih diterima, lalu otomatis
+// di-upgrade saat login berikutnya berhasil.
+
+const passwordHashLen = 32
+
+func HashPassword(p string) string {
+	salt := make([]byte, 16)
+	io.ReadFull(rand.Reader, salt)
+	hash := argon2.IDKey([]byte(p), salt, kdfTime, kdfMemory, kdfThreads, passwordHashLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, kdfMemory, kdfTime, kdfThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash))
+}
+
+// CheckPassword mencocokkan password dengan hash tersimpan.
+// needsUpgrade = true jika hash masih format lama / parameter lebih lemah.
+func CheckPassword(p, stored string) (ok bool, needsUpgrade bool) {
+	if stored == "" {
+		return false, false
+	}
+	if !strings.HasPrefix(stored, "$argon2id$") {
+		legacy := HashString(p)
+		return subtle.ConstantTimeCompare([]byte(legacy), []byte(stored)) == 1, true
+	}
+
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+	var version int
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false, false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false
+	}
+
+	got := argon2.IDKey([]byte(p), salt, iterations, memory, threads, uint32(len(want)))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return false, false
+	}
+	weaker := memory < kdfMemory || iterations < kdfTime || version != argon2.Version
+	return true, weaker
+}
+
+// --- BRUTE-FORCE THROTTLING ---
+//
+// Setiap scope ("master", "hidden", "book:<id>") punya penghitung gagal.
+// Setelah maxFreeAttempts kali salah, percobaan berikutnya harus menunggu
+// 1s, 2s, 4s, ... (maksimal maxLockout). Disimpan di GlobalConfig supaya
+// tidak bisa di-reset hanya dengan menutup aplikasi.
+
+const (
+	maxFreeAttempts = 3
+	maxLockout      = 15 * time.Minute
+)
+
+type attemptState struct {
+	Failures int       `json:"failures"`
+	Until    time.Time `json:"until"`
+}
+
+// attemptsKey: scope login ("master", "recovery") dipakai bersama semua
+// profil karena profilnya belum diketahui saat mencoba; scope lain
+// ("hidden", "book:<id>") milik profil yang sedang terbuka.
+func (a *App) attemptsKey(scope string) string {
+	if scope == "master" || scope == "recovery" {
+		return "attempts:" + scope
+	}
+	return a.cfg("attempts:" + scope)
+}
+
+func (a *App) loadAttempts(scope string) attemptState {
+	var st attemptState
+	if raw := a.getConfig(a.attemptsKey(scope)); raw != "" {
+		json.Unmarshal([]byte(raw), &st)
+	}
+	return st
+}
+
+// retryAfter mengembalikan sisa waktu tunggu untuk scope (0 = boleh mencoba).
+func (a *App) retryAfter(scope string) time.Duration {
+	a.attemptMu.Lock()
+	defer a.attemptMu.Unlock()
+	if wait := time.Until(a.loadAttempts(scope).Until); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// recordAttempt mencatat hasil percobaan password untuk scope.
+func (a *App) recordAttempt(scope string, success bool) {
+	a.attemptMu.Lock()
+	defer a.attemptMu.Unlock()
+	if success {
+		a.deleteConfig(a.attemptsKey(scope))
+		return
+	}
+	st := a.loadAttempts(scope)
+	st.Failures++
+	if st.Failures >= maxFreeAttempts {
+		delay := time.Second << min(st.Failures-maxFreeAttempts, 20)
+		st.Until = time.Now().Add(min(delay, maxLockout))
+	}
+	raw, _ := json.Marshal(st)
+	a.setConfig(a.attemptsKey(scope), string(raw))
+}
+
+// GetUnlockRetryAfter: detik yang harus ditunggu sebelum boleh mencoba
+// master password lagi (untuk ditampilkan di layar login).
+func (a *App) GetUnlockRetryAfter() int {
+	return int(a.retryAfter("master").Seconds() + 0.999)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestCheckPassword(t *testing.T) {
+	hash := HashPassword("rahasia")
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		t.Fatalf("format hash = %q", hash)
+	}
+	if HashPassword("rahasia") == hash {
+		t.Fatal("dua hash password yang sama identik (salt tidak acak)")
+	}
+
+	// Hash argon2id dengan parameter lebih lemah dari sekarang
+	salt := []byte("0123456789abcdef")
+	weak := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, kdfMemory, 1, kdfThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("rahasia"), salt, 1, kdfMemory, kdfThreads, passwordHashLen)))
+
+	tests := []struct {
+		name        string
+		password    string
+		stored      string
+		ok, upgrade bool
+	}{
+		{"argon2id benar", "rahasia", hash, true, false},
+		{"argon2id salah", "salah", hash, false, false},
+		{"SHA-256 lama benar", "rahasia", HashString("rahasia"), true, true},
+		{"SHA-256 lama salah", "salah", HashString("rahasia"), false, true},
+		{"parameter lemah benar", "rahasia", weak, true, true},
+		{"parameter lemah salah", "salah", weak, false, false},
+		{"hash kosong", "", "", false, false},
+		{"hash rusak", "rahasia", "$argon2id$v=19$m=1", false, false},
+		{"salt bukan base64", "rahasia", "$argon2id$v=19$m=65536,t=3,p=4$!!$AAAA", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, upgrade := CheckPassword(tt.password, tt.stored)
+			if ok != tt.ok || (ok && upgrade != tt.upgrade) {
+				t.Errorf("CheckPassword = %v, %v; want %v, %v", ok, upgrade, tt.ok, tt.upgrade)
+			}
+		})
+	}
+}
+
+// Hash SHA-256 lama di-upgrade ke argon2id saat login berikutnya berhasil,
+// dan tetap utuh kalau password salah.
+func TestCheckConfigPasswordUpgrade(t *testing.T) {
+	a := startTestApp(t, t.TempDir())
+	legacy := HashString("rahasia")
+	a.setConfig("hidden_hash", legacy)
+
+	if a.checkConfigPassword("hidden", "hidden_hash", "salah") {
+		t.Fatal("password salah diterima")
+	}
+	if got := a.getConfig("hidden_hash"); got != legacy {
+		t.Fatalf("hash berubah setelah password salah: %q", got)
+	}
+	if !a.checkConfigPassword("hidden", "hidden_hash", "rahasia") {
+		t.Fatal("password benar ditolak")
+	}
+	upgraded := a.getConfig("hidden_hash")
+	if !strings.HasPrefix(upgraded, "$argon2id$") {
+		t.Fatalf("hash tidak di-upgrade: %q", upgraded)
+	}
+	if ok, upgrade := CheckPassword("rahasia", upgraded); !ok || upgrade {
+		t.Fatalf("hash hasil upgrade = %v, %v", ok, upgrade)
+	}
+}
+
+// Hash buku SHA-256 lama di-upgrade di setiap kecocokan: untuk buku yang
+// sudah punya keyslot maupun buku terkunci versi lama (hanya hash).
+func TestVerifyBookPasswordUpgrade(t *testing.T) {
+	a := newTestApp(t)
+	key := testKey(t)
+	slot, err := newKeySlot("rahasia", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := HashString("rahasia")
+	tests := []struct {
+		name    string
+		keySlot string
+	}{
+		{"dengan keyslot", marshalKeySlot(slot)},
+		{"tanpa keyslot", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := addTestBook(t, a, Book{Title: tt.name, IsLocked: true, PasswordHash: legacy, KeySlot: tt.keySlot})
+			stored := func() string {
+				var b Book
+				a.db.First(&b, book.ID)
+				return b.PasswordHash
+			}
+
+			if a.VerifyBookPassword(book.Title, "salah") {
+				t.Fatal("password salah diterima")
+			}
+			if got := stored(); got != legacy {
+				t.Fatalf("hash berubah setelah password salah: %q", got)
+			}
+			if !a.VerifyBookPassword(book.Title, "rahasia") {
+				t.Fatal("password benar ditolak")
+			}
+			upgraded := stored()
+			if ok, upgrade := CheckPassword("rahasia", upgraded); !ok || upgrade {
+				t.Fatalf("hash tidak di-upgrade: %q", upgraded)
+			}
+			if k, ok := a.bookKey(book.ID); !ok || k == nil {
+				t.Fatal("kunci buku tidak dibuka")
+			}
+		})
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	a := startTestApp(t, t.TempDir())
+	if !a.ToggleHiddenZone("rahasia") {
+		t.Fatal("gagal membuat password Hidden Zone")
+	}
+	a.LockHiddenZone()
+
+	for i := 1; i < maxFreeAttempts; i++ {
+		if a.ToggleHiddenZone("salah") {
+			t.Fatal("password salah diterima")
+		}
+		if wait := a.retryAfter("hidden"); wait != 0 {
+			t.Fatalf("percobaan ke-%d sudah ditahan %v", i, wait)
+		}
+	}
+	if a.ToggleHiddenZone("salah") {
+		t.Fatal("password salah diterima")
+	}
+	if wait := a.retryAfter("hidden"); wait <= 0 || wait > time.Second {
+		t.Fatalf("tunggu setelah %d kali salah = %v, want (0, 1s]", maxFreeAttempts, wait)
+	}
+
+	// Selama ditahan, password benar pun ditolak
+	if a.ToggleHiddenZone("rahasia") || a.IsHiddenZoneActive() {
+		t.Fatal("password benar diterima saat masih ditahan")
+	}
+
+	// Back-off berlipat setiap kali salah lagi, sampai maxLockout
+	st := a.loadAttempts("hidden")
+	st.Until = time.Time{}
+	st.Failures = 40
+	raw, _ := json.Marshal(st)
+	a.setConfig(a.attemptsKey("hidden"), string(raw))
+	a.ToggleHiddenZone("salah")
+	if wait := a.retryAfter("hidden"); wait <= maxLockout-time.Minute || wait > maxLockout {
+		t.Fatalf("tunggu setelah 41 kali salah = %v, want ~%v", wait, maxLockout)
+	}
+
+	// Penghitung hidden milik profil yang sedang terbuka
+	a.useProfile(true)
+	if wait := a.retryAfter("hidden"); wait != 0 {
+		t.Errorf("profil lain ikut ditahan %v", wait)
+	}
+	a.useProfile(false)
+
+	// Setelah waktu tunggu lewat, password benar diterima dan penghitung direset
+	st = a.loadAttempts("hidden")
+	st.Until = time.Now().Add(-time.Second)
+	raw, _ = json.Marshal(st)
+	a.setConfig(a.attemptsKey("hidden"), string(raw))
+	if !a.ToggleHiddenZone("rahasia") {
+		t.Fatal("password benar ditolak setelah waktu tunggu lewat")
+	}
+	if st := a.loadAttempts("hidden"); st.Failures != 0 {
+		t.Errorf("penghitung tidak direset: %+v", st)
+	}
+}
@@ -0,0 +1,969 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// --- IMPORT PDF ---
+//
+// Parser PDF minimal, hanya untuk mengambil gambar halaman (hasil scan):
+// objek dicari langsung di file (tidak bergantung xref yang sering rusak),
+// object stream (PDF 1.5+) ikut dibaca, lalu page tree ditelusuri berurutan.
+// Di tiap halaman, gambar yang benar-benar digambar (operator "Do" di content
+// stream, termasuk di dalam Form XObject) diambil sesuai urutannya.
+//
+// Gambar DCTDecode = file JPEG utuh, dipakai apa adanya. Gambar FlateDecode
+// berisi piksel mentah dan di-decode di sini (Gray/RGB/CMYK/Indexed).
+// Format lain (JPX, CCITT, JBIG2) dilewati.
+
+var (
+	errPDFNoImages    = errors.New("PDF hanya berisi konten vektor/teks (tidak ada gambar halaman), tidak bisa diimpor")
+	errPDFUnsupported = errors.New("gambar di PDF ini memakai format yang belum didukung (JPX/CCITT/JBIG2)")
+	errPDFEncrypted   = errors.New("PDF terenkripsi/berpassword belum didukung")
+	errPDFFilter      = errors.New("filter stream tidak didukung")
+)
+
+// Gambar lebih kecil dari ini dianggap hiasan (logo, ikon), bukan halaman
+const pdfMinImageSide = 64
+
+// Batas hasil decode satu stream, supaya stream kecil yang mengembang
+// (zip bomb) tidak menghabiskan memori. Variabel supaya test bisa memakai
+// batas yang lebih kecil.
+var pdfMaxStreamSize = 256 << 20
+
+// Batas kedalaman color space bertingkat (ICCBased /Alternate, Indexed)
+const pdfMaxColorSpaceDepth = 8
+
+type pdfName string
+
+type pdfRef struct{ num, gen int }
+
+type pdfDict map[pdfName]any
+
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte // masih terenkode (sesuai /Filter)
+}
+
+type pdfFile struct {
+	data    []byte
+	objects map[int]any
+	trailer pdfDict
+}
+
+// pdfImage: satu gambar halaman yang akan diimpor.
+type pdfImage struct {
+	stream *pdfStream
+	jpeg   bool // DCTDecode tanpa filter lain: raw = file JPEG
+}
+
+func scanPDF(pdfPath string) (*importSource, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+	images, err := doc.pageImages()
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]importPage, len(images))
+	for i, img := range images {
+		img := img
+		page := importPage{Rel: fmt.Sprintf("%04d.jpg", i+1)}
+		if img.jpeg {
+			page.Open = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(img.stream.raw)), nil
+			}
+		} else {
+			page.Decode = func() (image.Image, error) { return doc.decodeImage(img.stream) }
+		}
+		pages[i] = page
+	}
+	return &importSource{Pages: pages}, nil
+}
+
+// --- STRUKTUR FILE ---
+
+var pdfObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+func parsePDF(data []byte) (*pdfFile, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data[:min(len(data), 1024)], "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, fmt.Errorf("bukan file PDF")
+	}
+	doc := &pdfFile{data: data, objects: map[int]any{}}
+
+	// 1. Semua "N G obj ... endobj" (versi terakhir menang, seperti incremental update)
+	for pos := 0; pos < len(data); {
+		loc := pdfObjHeader.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		obj, end, err := doc.parseIndirect(pos + loc[1])
+		if err != nil {
+			pos += loc[1]
+			continue
+		}
+		doc.objects[num] = obj
+		if s, ok := obj.(*pdfStream); ok && s.dict["Type"] == pdfName("XRef") {
+			doc.trailer = s.dict
+		}
+		pos = end
+	}
+
+	// 2. Trailer klasik (yang terakhir)
+	if i := bytes.LastIndex(data, []byte("trailer")); i >= 0 {
+		p := &pdfParser{data: data, pos: i + len("trailer")}
+		if d, ok := p.object().(pdfDict); ok && p.err == nil {
+			doc.trailer = d
+		}
+	}
+	if doc.trailer != nil && doc.trailer["Encrypt"] != nil {
+		return nil, errPDFEncrypted
+	}
+
+	// 3. Objek di dalam object stream
+	for _, obj := range doc.objects {
+		if s, ok := obj.(*pdfStream); ok && s.dict["Type"] == pdfName("ObjStm") {
+			doc.loadObjectStream(s)
+		}
+	}
+	return doc, nil
+}
+
+func (doc *pdfFile) parseIndirect(pos int) (any, int, error) {
+	p := &pdfParser{data: doc.data, pos: pos}
+	obj := p.object()
+	if p.err != nil {
+		return nil, 0, p.err
+	}
+	p.skipSpace()
+	if dict, ok := obj.(pdfDict); ok && bytes.HasPrefix(doc.data[p.pos:], []byte("stream")) {
+		start := p.pos + len("stream")
+		if bytes.HasPrefix(doc.data[start:], []byte("\r\n")) {
+			start += 2
+		} else if start < len(doc.data) && (doc.data[start] == '\n' || doc.data[start] == '\r') {
+			start++
+		}
+		// /Length bisa berupa referensi yang belum terbaca: cari endstream saja
+		end := bytes.Index(doc.data[start:], []byte("endstream"))
+		if end < 0 {
+			return nil, 0, fmt.Errorf("endstream tidak ditemukan")
+		}
+		raw := doc.data[start : start+end]
+		if n, ok := dict["Length"].(int); ok && n >= 0 && n <= len(raw) {
+			raw = raw[:n]
+		} else {
+			raw = bytes.TrimRight(raw, "\r\n")
+		}
+		obj = &pdfStream{dict: dict, raw: raw}
+		p.pos = start + end + len("endstream")
+	}
+	if i := bytes.Index(doc.data[p.pos:min(len(doc.data), p.pos+64)], []byte("endobj")); i >= 0 {
+		p.pos += i + len("endobj")
+	}
+	return obj, p.pos, nil
+}
+
+func (doc *pdfFile) loadObjectStream(s *pdfStream) {
+	data, err := doc.streamData(s)
+	if err != nil {
+		return
+	}
+	n, _ := doc.resolve(s.dict["N"]).(int)
+	first, _ := doc.resolve(s.dict["First"]).(int)
+	if first > len(data) {
+		return
+	}
+	head := &pdfParser{data: data[:first]}
+	for i := 0; i < n; i++ {
+		num, ok1 := head.object().(int)
+		off, ok2 := head.object().(int)
+		if !ok1 || !ok2 || head.err != nil {
+			return
+		}
+		if _, exists := doc.objects[num]; exists || first+off >= len(data) {
+			continue
+		}
+		p := &pdfParser{data: data, pos: first + off}
+		if obj := p.object(); p.err == nil {
+			doc.objects[num] = obj
+		}
+	}
+}
+
+func (doc *pdfFile) resolve(v any) any {
+	for i := 0; i < 32; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = doc.objects[ref.num]
+	}
+	return nil
+}
+
+func (doc *pdfFile) dict(v any) pdfDict {
+	switch v := doc.resolve(v).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+func (doc *pdfFile) array(v any) []any {
+	switch v := doc.resolve(v).(type) {
+	case []any:
+		return v
+	case nil:
+		return nil
+	default:
+		return []any{v}
+	}
+}
+
+func (doc *pdfFile) number(v any) float64 {
+	switch v := doc.resolve(v).(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+// --- HALAMAN & GAMBAR ---
+
+func (doc *pdfFile) root() pdfDict {
+	if root := doc.dict(doc.trailer["Root"]); root != nil {
+		return root
+	}
+	// Trailer rusak/tidak ada: cari katalog langsung
+	for _, obj := range doc.objects {
+		if d, ok := obj.(pdfDict); ok && d["Type"] == pdfName("Catalog") {
+			return d
+		}
+	}
+	return nil
+}
+
+func (doc *pdfFile) pageImages() ([]pdfImage, error) {
+	root := doc.root()
+	if root == nil {
+		return nil, fmt.Errorf("struktur PDF rusak (katalog tidak ditemukan)")
+	}
+	var images []pdfImage
+	var unsupported int
+	visited := map[*pdfStream]bool{}
+	doc.walkPages(root["Pages"], nil, map[any]bool{}, func(page pdfDict, res pdfDict) {
+		doc.walkContent(page["Contents"], res, 0, func(s *pdfStream) {
+			// Gambar yang dipakai ulang (logo/watermark di tiap halaman) hanya diambil sekali
+			if visited[s] {
+				return
+			}
+			visited[s] = true
+			if doc.number(s.dict["Width"]) < pdfMinImageSide || doc.number(s.dict["Height"]) < pdfMinImageSide {
+				return
+			}
+			img, ok := doc.classifyImage(s)
+			if !ok {
+				unsupported++
+				return
+			}
+			images = append(images, img)
+		})
+	})
+	if len(images) == 0 {
+		if unsupported > 0 {
+			return nil, errPDFUnsupported
+		}
+		return nil, errPDFNoImages
+	}
+	return images, nil
+}
+
+func (doc *pdfFile) walkPages(node any, inherited pdfDict, seen map[any]bool, fn func(page, res pdfDict)) {
+	if ref, ok := node.(pdfRef); ok {
+		if seen[ref] {
+			return
+		}
+		seen[ref] = true
+	}
+	d := doc.dict(node)
+	if d == nil {
+		return
+	}
+	res := inherited
+	if r := doc.dict(d["Resources"]); r != nil {
+		res = r
+	}
+	if kids, ok := doc.resolve(d["Kids"]).([]any); ok && d["Type"] != pdfName("Page") {
+		for _, kid := range kids {
+			doc.walkPages(kid, res, seen, fn)
+		}
+		return
+	}
+	fn(d, res)
+}
+
+var pdfDoOperator = regexp.MustCompile(`/([^\s/\[\]()<>{}%]+)\s+Do\b`)
+
+// walkContent memanggil fn untuk setiap gambar yang digambar oleh content
+// stream, sesuai urutan. Form XObject ditelusuri (maks. 4 tingkat).
+func (doc *pdfFile) walkContent(contents any, res pdfDict, depth int, fn func(*pdfStream)) {
+	if depth > 4 {
+		return
+	}
+	var content []byte
+	for _, c := range doc.array(contents) {
+		s, ok := doc.resolve(c).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := doc.streamData(s)
+		if err != nil {
+			continue
+		}
+		content = append(append(content, data...), '\n')
+	}
+	xobjects := doc.dict(res["XObject"])
+	for _, m := range pdfDoOperator.FindAllSubmatch(content, -1) {
+		s, ok := doc.resolve(xobjects[pdfName(m[1])]).(*pdfStream)
+		if !ok {
+			continue
+		}
+		switch s.dict["Subtype"] {
+		case pdfName("Image"):
+			fn(s)
+		case pdfName("Form"):
+			formRes := res
+			if r := doc.dict(s.dict["Resources"]); r != nil {
+				formRes = r
+			}
+			doc.walkContent(s, formRes, depth+1, fn)
+		}
+	}
+}
+
+// classifyImage memeriksa filter gambar: ok = bisa di-decode (Flate dan/atau
+// DCT di akhir, BitsPerComponent yang didukung). jpeg = stream-nya sendiri
+// sudah file JPEG.
+func (doc *pdfFile) classifyImage(s *pdfStream) (pdfImage, bool) {
+	img := pdfImage{stream: s}
+	var filters []pdfName
+	for _, f := range doc.array(s.dict["Filter"]) {
+		name, _ := doc.resolve(f).(pdfName)
+		filters = append(filters, name)
+	}
+	for i, f := range filters {
+		switch f {
+		case "FlateDecode", "Fl":
+		case "DCTDecode", "DCT":
+			if i != len(filters)-1 {
+				return img, false
+			}
+		default:
+			return img, false
+		}
+	}
+	img.jpeg = len(filters) == 1 && (filters[0] == "DCTDecode" || filters[0] == "DCT")
+	if n := len(filters); n == 0 || (filters[n-1] != "DCTDecode" && filters[n-1] != "DCT") {
+		// Piksel mentah: kedalaman bit harus yang bisa dibaca decodeImage
+		if _, err := doc.imageBPC(s); err != nil {
+			return img, false
+		}
+	}
+	return img, true
+}
+
+// imageBPC: BitsPerComponent gambar piksel mentah (1, 2, 4, 8 atau 16).
+func (doc *pdfFile) imageBPC(s *pdfStream) (int, error) {
+	if s.dict["ImageMask"] == true {
+		return 1, nil
+	}
+	bpc := int(doc.number(s.dict["BitsPerComponent"]))
+	switch bpc {
+	case 0:
+		return 8, nil
+	case 1, 2, 4, 8, 16:
+		return bpc, nil
+	}
+	return 0, fmt.Errorf("BitsPerComponent %d tidak didukung", bpc)
+}
+
+// streamData mengembalikan isi stream yang sudah di-decode (Flate saja).
+func (doc *pdfFile) streamData(s *pdfStream) ([]byte, error) {
+	data := s.raw
+	params := doc.array(s.dict["DecodeParms"])
+	for i, f := range doc.array(s.dict["Filter"]) {
+		switch doc.resolve(f) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			out, err := io.ReadAll(io.LimitReader(zr, int64(pdfMaxStreamSize)+1))
+			if len(out) > pdfMaxStreamSize {
+				return nil, fmt.Errorf("stream PDF terlalu besar (lebih dari %d MB)", pdfMaxStreamSize>>20)
+			}
+			if err != nil && len(out) == 0 {
+				// Stream yang terpotong masih sering berisi data yang valid
+				return nil, err
+			}
+			data = out
+			if i < len(params) {
+				if data, err = doc.unpredict(data, doc.dict(params[i])); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, errPDFFilter
+		}
+	}
+	return data, nil
+}
+
+// unpredict membalik PNG predictor (Predictor >= 10) dari DecodeParms.
+func (doc *pdfFile) unpredict(data []byte, parms pdfDict) ([]byte, error) {
+	predictor := int(doc.number(parms["Predictor"]))
+	if predictor < 2 {
+		return data, nil
+	}
+	if predictor < 10 {
+		return nil, fmt.Errorf("predictor %d tidak didukung", predictor)
+	}
+	colors, bpc, columns := 1, 8, 1
+	if v := doc.number(parms["Colors"]); v > 0 {
+		colors = int(min(v, 32))
+	}
+	if v := doc.number(parms["BitsPerComponent"]); v > 0 {
+		bpc = int(min(v, 16))
+	}
+	if v := doc.number(parms["Columns"]); v > 0 {
+		columns = int(min(v, 1<<24))
+	}
+	bpp := max(1, colors*bpc/8)
+	rowLen := (colors*bpc*columns + 7) / 8
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowLen)
+	for len(data) >= rowLen+1 {
+		ft, row := data[0], append([]byte(nil), data[1:rowLen+1]...)
+		data = data[rowLen+1:]
+		for x := range row {
+			var left, upLeft byte
+			if x >= bpp {
+				left, upLeft = row[x-bpp], prev[x-bpp]
+			}
+			up := prev[x]
+			switch ft {
+			case 1:
+				row[x] += left
+			case 2:
+				row[x] += up
+			case 3:
+				row[x] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[x] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := absInt(p-int(a)), absInt(p-int(b)), absInt(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+// pdfDimension: ukuran dari dictionary, di luar rentang int dianggap tidak valid.
+func pdfDimension(v float64) int {
+	if v < 1 || v > 1<<30 {
+		return 0
+	}
+	return int(v)
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// decodeImage mengubah image XObject FlateDecode (piksel mentah) atau
+// Flate+DCT menjadi image.Image.
+func (doc *pdfFile) decodeImage(s *pdfStream) (image.Image, error) {
+	data, err := doc.streamDataUntilDCT(s)
+	if err != nil {
+		return nil, err
+	}
+	if data.jpeg {
+		img, _, err := image.Decode(bytes.NewReader(data.bytes))
+		return img, err
+	}
+
+	w, h := pdfDimension(doc.number(s.dict["Width"])), pdfDimension(doc.number(s.dict["Height"]))
+	if w <= 0 || h <= 0 || w > (1<<28)/h {
+		return nil, fmt.Errorf("ukuran gambar PDF tidak valid (%dx%d)", w, h)
+	}
+	bpc, err := doc.imageBPC(s)
+	if err != nil {
+		return nil, err
+	}
+
+	space, err := doc.colorSpace(s.dict["ColorSpace"], 0)
+	if err != nil {
+		return nil, err
+	}
+	if s.dict["ImageMask"] == true {
+		space = pdfColorSpace{components: 1}
+	}
+	if space.components == 0 {
+		return nil, fmt.Errorf("color space PDF tidak didukung")
+	}
+	if space.palette != nil && bpc > 8 {
+		// Indeks palet maksimal 8 bit (PDF 32000-1 8.6.6.3)
+		return nil, fmt.Errorf("BitsPerComponent %d tidak valid untuk Indexed", bpc)
+	}
+	rowLen := (w*space.components*bpc + 7) / 8
+	if len(data.bytes) < rowLen*h {
+		return nil, fmt.Errorf("data gambar PDF terpotong")
+	}
+	invert := false
+	if dec := doc.array(s.dict["Decode"]); len(dec) >= 2 && doc.number(dec[0]) == 1 && doc.number(dec[1]) == 0 {
+		invert = true
+	}
+
+	sample := func(row []byte, i int) uint8 {
+		switch bpc {
+		case 8:
+			return row[i]
+		case 16:
+			// Big-endian: byte tinggi cukup untuk 8 bit per channel
+			return row[i*2]
+		default:
+			bit := i * bpc
+			v := (row[bit/8] >> (8 - bpc - bit%8)) & (1<<bpc - 1)
+			if space.palette != nil {
+				return v
+			}
+			return v * uint8(255/(1<<bpc-1))
+		}
+	}
+
+	rect := image.Rect(0, 0, w, h)
+	switch {
+	case space.palette != nil:
+		img := image.NewPaletted(rect, space.palette)
+		for y := 0; y < h; y++ {
+			row := data.bytes[y*rowLen:]
+			for x := 0; x < w; x++ {
+				img.Pix[y*img.Stride+x] = min(sample(row, x), uint8(len(space.palette)-1))
+			}
+		}
+		return img, nil
+	case space.components == 1:
+		img := image.NewGray(rect)
+		for y := 0; y < h; y++ {
+			row := data.bytes[y*rowLen:]
+			for x := 0; x < w; x++ {
+				v := sample(row, x)
+				if invert {
+					v = 255 - v
+				}
+				img.Pix[y*img.Stride+x] = v
+			}
+		}
+		return img, nil
+	case space.components == 3:
+		img := image.NewRGBA(rect)
+		for y := 0; y < h; y++ {
+			row := data.bytes[y*rowLen:]
+			for x := 0; x < w; x++ {
+				o := y*img.Stride + x*4
+				img.Pix[o] = sample(row, x*3)
+				img.Pix[o+1] = sample(row, x*3+1)
+				img.Pix[o+2] = sample(row, x*3+2)
+				img.Pix[o+3] = 0xFF
+			}
+		}
+		return img, nil
+	case space.components == 4:
+		img := image.NewCMYK(rect)
+		for y := 0; y < h; y++ {
+			row := data.bytes[y*rowLen:]
+			for x := 0; x < w; x++ {
+				for c := 0; c < 4; c++ {
+					img.Pix[y*img.Stride+x*4+c] = sample(row, x*4+c)
+				}
+			}
+		}
+		return img, nil
+	}
+	return nil, fmt.Errorf("color space PDF tidak didukung")
+}
+
+type pdfDecoded struct {
+	bytes []byte
+	jpeg  bool
+}
+
+// streamDataUntilDCT: seperti streamData, tapi berhenti di DCTDecode
+// (hasilnya file JPEG).
+func (doc *pdfFile) streamDataUntilDCT(s *pdfStream) (pdfDecoded, error) {
+	filters := doc.array(s.dict["Filter"])
+	if n := len(filters); n > 0 {
+		if last := doc.resolve(filters[n-1]); last == pdfName("DCTDecode") || last == pdfName("DCT") {
+			// Hanya Flate yang mungkin ada sebelum DCT (lihat classifyImage)
+			inner := &pdfStream{dict: pdfDict{"Filter": filters[:n-1]}, raw: s.raw}
+			data, err := doc.streamData(inner)
+			return pdfDecoded{bytes: data, jpeg: true}, err
+		}
+	}
+	data, err := doc.streamData(s)
+	return pdfDecoded{bytes: data}, err
+}
+
+type pdfColorSpace struct {
+	components int
+	palette    color.Palette // hanya untuk Indexed
+}
+
+// colorSpace membaca /ColorSpace; components 0 berarti tidak didukung.
+// depth menghitung tingkat color space bertingkat yang sedang dibaca.
+func (doc *pdfFile) colorSpace(v any, depth int) (pdfColorSpace, error) {
+	if depth > pdfMaxColorSpaceDepth {
+		return pdfColorSpace{}, fmt.Errorf("color space PDF bertingkat terlalu dalam")
+	}
+	switch cs := doc.resolve(v).(type) {
+	case pdfName:
+		switch cs {
+		case "DeviceGray", "CalGray", "G":
+			return pdfColorSpace{components: 1}, nil
+		case "DeviceRGB", "CalRGB", "RGB":
+			return pdfColorSpace{components: 3}, nil
+		case "DeviceCMYK", "CMYK":
+			return pdfColorSpace{components: 4}, nil
+		}
+	case []any:
+		if len(cs) == 0 {
+			break
+		}
+		switch doc.resolve(cs[0]) {
+		case pdfName("CalGray"):
+			return pdfColorSpace{components: 1}, nil
+		case pdfName("CalRGB"), pdfName("Lab"):
+			return pdfColorSpace{components: 3}, nil
+		case pdfName("ICCBased"):
+			if len(cs) > 1 {
+				if s, ok := doc.resolve(cs[1]).(*pdfStream); ok {
+					if n := int(doc.number(s.dict["N"])); n == 1 || n == 3 || n == 4 {
+						return pdfColorSpace{components: n}, nil
+					}
+					return doc.colorSpace(s.dict["Alternate"], depth+1)
+				}
+			}
+		case pdfName("Indexed"), pdfName("I"):
+			if len(cs) >= 4 {
+				return doc.indexedColorSpace(cs, depth)
+			}
+		}
+	}
+	return pdfColorSpace{}, nil
+}
+
+// indexedColorSpace: [/Indexed base hival lookup]
+func (doc *pdfFile) indexedColorSpace(cs []any, depth int) (pdfColorSpace, error) {
+	base, err := doc.colorSpace(cs[1], depth+1)
+	if err != nil || base.components == 0 || base.palette != nil {
+		return pdfColorSpace{}, err
+	}
+	// hival maksimal 255 (PDF 32000-1 8.6.6.3)
+	hival := doc.number(cs[2])
+	if !(hival >= 0 && hival <= 255) {
+		return pdfColorSpace{}, fmt.Errorf("hival %v tidak valid untuk Indexed", hival)
+	}
+	var lookup []byte
+	switch l := doc.resolve(cs[3]).(type) {
+	case string:
+		lookup = []byte(l)
+	case *pdfStream:
+		lookup, _ = doc.streamData(l)
+	}
+	n := base.components
+	palette := make(color.Palette, 0, int(hival)+1)
+	for i := 0; i <= int(hival) && (i+1)*n <= len(lookup); i++ {
+		c := lookup[i*n : (i+1)*n]
+		switch n {
+		case 1:
+			palette = append(palette, color.Gray{Y: c[0]})
+		case 3:
+			palette = append(palette, color.RGBA{R: c[0], G: c[1], B: c[2], A: 0xFF})
+		case 4:
+			palette = append(palette, color.CMYK{C: c[0], M: c[1], Y: c[2], K: c[3]})
+		}
+	}
+	if len(palette) == 0 {
+		return pdfColorSpace{}, nil
+	}
+	return pdfColorSpace{components: 1, palette: palette}, nil
+}
+
+// --- LEXER ---
+
+type pdfParser struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (p *pdfParser) skipSpace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '%' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *pdfParser) fail(format string, args ...any) any {
+	if p.err == nil {
+		p.err = fmt.Errorf(format, args...)
+	}
+	return nil
+}
+
+func (p *pdfParser) token() []byte {
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelim(p.data[p.pos]) {
+		p.pos++
+	}
+	return p.data[start:p.pos]
+}
+
+// object membaca satu objek PDF. Hasil: nil, bool, int, float64, string,
+// pdfName, []any, pdfDict, atau pdfRef.
+func (p *pdfParser) object() any {
+	if p.err != nil {
+		return nil
+	}
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return p.fail("akhir data")
+	}
+	switch c := p.data[p.pos]; {
+	case c == '/':
+		p.pos++
+		return pdfName(unescapeName(p.token()))
+	case c == '<' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '<':
+		p.pos += 2
+		d := pdfDict{}
+		for {
+			p.skipSpace()
+			if p.err != nil || p.pos >= len(p.data) {
+				return p.fail("dictionary tidak ditutup")
+			}
+			if bytes.HasPrefix(p.data[p.pos:], []byte(">>")) {
+				p.pos += 2
+				return d
+			}
+			key, ok := p.object().(pdfName)
+			if !ok {
+				return p.fail("key dictionary bukan name")
+			}
+			d[key] = p.object()
+		}
+	case c == '<':
+		end := bytes.IndexByte(p.data[p.pos:], '>')
+		if end < 0 {
+			return p.fail("hex string tidak ditutup")
+		}
+		hex := p.data[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		return decodeHexString(hex)
+	case c == '(':
+		return p.literalString()
+	case c == '[':
+		p.pos++
+		var arr []any
+		for {
+			p.skipSpace()
+			if p.err != nil || p.pos >= len(p.data) {
+				return p.fail("array tidak ditutup")
+			}
+			if p.data[p.pos] == ']' {
+				p.pos++
+				return arr
+			}
+			arr = append(arr, p.object())
+		}
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	default:
+		switch tok := string(p.token()); tok {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		default:
+			if tok == "" {
+				p.pos++
+			}
+			return p.fail("token tidak dikenal %q", tok)
+		}
+	}
+}
+
+func (p *pdfParser) number() any {
+	tok := string(p.token())
+	n, err := strconv.Atoi(tok)
+	if err != nil {
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return p.fail("angka tidak valid %q", tok)
+		}
+		return f
+	}
+	// Referensi "N G R"?
+	save := p.pos
+	p.skipSpace()
+	if gen, err := strconv.Atoi(string(p.token())); err == nil {
+		p.skipSpace()
+		if p.pos < len(p.data) && p.data[p.pos] == 'R' &&
+			(p.pos+1 == len(p.data) || isPDFSpace(p.data[p.pos+1]) || isPDFDelim(p.data[p.pos+1])) {
+			p.pos++
+			return pdfRef{num: n, gen: gen}
+		}
+	}
+	p.pos = save
+	return n
+}
+
+func (p *pdfParser) literalString() any {
+	var out []byte
+	depth := 0
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		c := p.data[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.data):
+			p.pos++
+			switch e := p.data[p.pos]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					for i := 0; i < 3 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						v = v*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					p.pos--
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		case c == '(':
+			depth++
+			out = append(out, c)
+		case c == ')':
+			if depth == 0 {
+				p.pos++
+				return string(out)
+			}
+			depth--
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return p.fail("string tidak ditutup")
+}
+
+func unescapeName(b []byte) string {
+	if bytes.IndexByte(b, '#') < 0 {
+		return string(b)
+	}
+	var out []byte
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			if v, err := strconv.ParseUint(string(b[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
+
+func decodeHexString(hex []byte) string {
+	var digits []byte
+	for _, c := range hex {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[i*2:i*2+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return string(out)
+}
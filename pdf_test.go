@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// pdfTestImage: satu halaman berisi satu image XObject.
+type pdfTestImage struct {
+	dict string // isi dictionary tanpa << >> dan /Length
+	raw  []byte
+}
+
+// buildPDF menyusun PDF minimal: satu halaman per gambar, urut.
+func buildPDF(images ...pdfTestImage) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	kids := ""
+	for i := range images {
+		kids += fmt.Sprintf("%d 0 R ", 3+i*3)
+	}
+	fmt.Fprintf(&b, "1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&b, "2 0 obj\n<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", kids, len(images))
+	for i, img := range images {
+		page, content, xobj := 3+i*3, 4+i*3, 5+i*3
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Type /Page /Parent 2 0 R /Contents %d 0 R /Resources << /XObject << /Im0 %d 0 R >> >> >>\nendobj\n", page, content, xobj)
+		ops := "q 100 0 0 100 0 0 cm /Im0 Do Q"
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", content, len(ops), ops)
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Type /XObject /Subtype /Image %s /Length %d >>\nstream\n", xobj, img.dict, len(img.raw))
+		b.Write(img.raw)
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func flate(data []byte) []byte {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	zw.Write(data)
+	zw.Close()
+	return b.Bytes()
+}
+
+func flateImage(w, h int, extra string, pix []byte) pdfTestImage {
+	return pdfTestImage{
+		dict: fmt.Sprintf("/Width %d /Height %d %s /Filter /FlateDecode", w, h, extra),
+		raw:  flate(pix),
+	}
+}
+
+// decodeTestImage mem-parse PDF satu gambar lalu men-decode gambarnya.
+func decodeTestImage(t *testing.T, img pdfTestImage) (image.Image, error) {
+	t.Helper()
+	doc, err := parsePDF(buildPDF(img))
+	if err != nil {
+		t.Fatalf("parsePDF: %v", err)
+	}
+	s, ok := doc.resolve(pdfRef{num: 5}).(*pdfStream)
+	if !ok {
+		t.Fatalf("image XObject tidak ditemukan")
+	}
+	return doc.decodeImage(s)
+}
+
+func TestPDFDecodeImageBitsPerComponent(t *testing.T) {
+	tests := []struct {
+		name    string
+		img     pdfTestImage
+		wantErr bool
+		want    []uint8 // piksel Gray baris pertama
+	}{
+		{name: "1 bit", img: flateImage(8, 1, "/ColorSpace /DeviceGray /BitsPerComponent 1", []byte{0b10100000}), want: []uint8{255, 0, 255, 0, 0, 0, 0, 0}},
+		{name: "2 bit", img: flateImage(4, 1, "/ColorSpace /DeviceGray /BitsPerComponent 2", []byte{0b00011011}), want: []uint8{0, 85, 170, 255}},
+		{name: "4 bit", img: flateImage(2, 1, "/ColorSpace /DeviceGray /BitsPerComponent 4", []byte{0xF0}), want: []uint8{255, 0}},
+		{name: "8 bit", img: flateImage(3, 1, "/ColorSpace /DeviceGray /BitsPerComponent 8", []byte{1, 2, 3}), want: []uint8{1, 2, 3}},
+		{name: "default 8 bit", img: flateImage(2, 1, "/ColorSpace /DeviceGray", []byte{7, 9}), want: []uint8{7, 9}},
+		{name: "16 bit", img: flateImage(2, 1, "/ColorSpace /DeviceGray /BitsPerComponent 16", []byte{0xAB, 0xCD, 0x12, 0x34}), want: []uint8{0xAB, 0x12}},
+		{name: "image mask", img: flateImage(8, 1, "/ImageMask true", []byte{0xFF}), want: []uint8{255, 255, 255, 255, 255, 255, 255, 255}},
+		{name: "3 bit", img: flateImage(8, 1, "/ColorSpace /DeviceGray /BitsPerComponent 3", make([]byte, 3)), wantErr: true},
+		{name: "5 bit", img: flateImage(8, 1, "/ColorSpace /DeviceGray /BitsPerComponent 5", make([]byte, 5)), wantErr: true},
+		{name: "12 bit", img: flateImage(2, 1, "/ColorSpace /DeviceGray /BitsPerComponent 12", make([]byte, 3)), wantErr: true},
+		{name: "32 bit", img: flateImage(1, 1, "/ColorSpace /DeviceGray /BitsPerComponent 32", make([]byte, 4)), wantErr: true},
+		{name: "negatif", img: flateImage(1, 1, "/ColorSpace /DeviceGray /BitsPerComponent -8", make([]byte, 1)), wantErr: true},
+		{name: "indexed 16 bit", img: flateImage(1, 1, "/ColorSpace [/Indexed /DeviceRGB 1 <000000FFFFFF>] /BitsPerComponent 16", make([]byte, 2)), wantErr: true},
+		{name: "data terpotong", img: flateImage(4, 4, "/ColorSpace /DeviceGray /BitsPerComponent 8", make([]byte, 3)), wantErr: true},
+		{name: "ukuran raksasa", img: flateImage(1<<20, 1<<20, "/ColorSpace /DeviceGray", nil), wantErr: true},
+		{name: "color space tidak dikenal", img: flateImage(1, 1, "/ColorSpace /Pattern", []byte{0}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodeTestImage(t, tt.img)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("error diharapkan, dapat gambar %T", img)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeImage: %v", err)
+			}
+			gray, ok := img.(*image.Gray)
+			if !ok {
+				t.Fatalf("hasil %T, ingin *image.Gray", img)
+			}
+			if got := gray.Pix[:len(tt.want)]; !bytes.Equal(got, tt.want) {
+				t.Fatalf("piksel %v, ingin %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFDecodeImageColorSpaces(t *testing.T) {
+	tests := []struct {
+		name string
+		img  pdfTestImage
+		want color.Color
+	}{
+		{name: "RGB 8 bit", img: flateImage(1, 1, "/ColorSpace /DeviceRGB /BitsPerComponent 8", []byte{10, 20, 30}), want: color.RGBA{10, 20, 30, 255}},
+		{name: "RGB 16 bit", img: flateImage(1, 1, "/ColorSpace /DeviceRGB /BitsPerComponent 16", []byte{10, 0xFF, 20, 0xFF, 30, 0xFF}), want: color.RGBA{10, 20, 30, 255}},
+		{name: "CMYK", img: flateImage(1, 1, "/ColorSpace /DeviceCMYK /BitsPerComponent 8", []byte{1, 2, 3, 4}), want: color.CMYK{1, 2, 3, 4}},
+		{name: "Indexed 2 bit", img: flateImage(1, 1, "/ColorSpace [/Indexed /DeviceRGB 2 <000000FF000000FF00>] /BitsPerComponent 2", []byte{0b10000000}), want: color.RGBA{0, 255, 0, 255}},
+		{name: "Decode terbalik", img: flateImage(1, 1, "/ColorSpace /DeviceGray /BitsPerComponent 8 /Decode [1 0]", []byte{0}), want: color.Gray{255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodeTestImage(t, tt.img)
+			if err != nil {
+				t.Fatalf("decodeImage: %v", err)
+			}
+			r1, g1, b1, a1 := img.At(0, 0).RGBA()
+			r2, g2, b2, a2 := tt.want.RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				t.Fatalf("warna %v, ingin %v", img.At(0, 0), tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFPageImages(t *testing.T) {
+	var jpg bytes.Buffer
+	jpeg.Encode(&jpg, image.NewGray(image.Rect(0, 0, 80, 80)), nil)
+	gray := bytes.Repeat([]byte{0x80}, 80*80)
+
+	tests := []struct {
+		name      string
+		pdf       []byte
+		wantErr   error
+		wantJPEGs []bool
+	}{
+		{
+			name: "urut halaman",
+			pdf: buildPDF(
+				pdfTestImage{dict: "/Width 80 /Height 80 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode", raw: jpg.Bytes()},
+				flateImage(80, 80, "/ColorSpace /DeviceGray /BitsPerComponent 8", gray),
+			),
+			wantJPEGs: []bool{true, false},
+		},
+		{
+			name:    "bit depth tidak didukung",
+			pdf:     buildPDF(flateImage(80, 80, "/ColorSpace /DeviceGray /BitsPerComponent 12", gray)),
+			wantErr: errPDFUnsupported,
+		},
+		{
+			name:    "JPX",
+			pdf:     buildPDF(pdfTestImage{dict: "/Width 80 /Height 80 /Filter /JPXDecode", raw: []byte("x")}),
+			wantErr: errPDFUnsupported,
+		},
+		{
+			name:    "gambar kecil (hiasan)",
+			pdf:     buildPDF(flateImage(8, 8, "/ColorSpace /DeviceGray /BitsPerComponent 8", make([]byte, 64))),
+			wantErr: errPDFNoImages,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parsePDF(tt.pdf)
+			if err != nil {
+				t.Fatalf("parsePDF: %v", err)
+			}
+			images, err := doc.pageImages()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, ingin %v", err, tt.wantErr)
+			}
+			if len(images) != len(tt.wantJPEGs) {
+				t.Fatalf("%d gambar, ingin %d", len(images), len(tt.wantJPEGs))
+			}
+			for i, img := range images {
+				if img.jpeg != tt.wantJPEGs[i] {
+					t.Fatalf("gambar %d: jpeg=%v", i, img.jpeg)
+				}
+				if !img.jpeg {
+					if _, err := doc.decodeImage(img.stream); err != nil {
+						t.Fatalf("gambar %d: %v", i, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// PDF yang dibuat untuk menghabiskan memori/stack ditolak dengan error.
+func TestPDFDecodeImageLimits(t *testing.T) {
+	defer func(n int) { pdfMaxStreamSize = n }(pdfMaxStreamSize)
+	pdfMaxStreamSize = 1 << 20
+
+	tests := []struct {
+		name  string
+		img   pdfTestImage
+		extra string // objek tambahan
+	}{
+		{name: "hival terlalu besar", img: flateImage(1, 1, "/ColorSpace [/Indexed /DeviceRGB 1000000000 <000000FFFFFF>] /BitsPerComponent 8", []byte{0})},
+		{name: "hival negatif", img: flateImage(1, 1, "/ColorSpace [/Indexed /DeviceRGB -1 <000000>] /BitsPerComponent 8", []byte{0})},
+		{
+			name:  "ICCBased /Alternate melingkar",
+			img:   flateImage(1, 1, "/ColorSpace [/ICCBased 9 0 R] /BitsPerComponent 8", []byte{0}),
+			extra: "9 0 obj\n<< /N 2 /Alternate [/ICCBased 9 0 R] /Length 0 >>\nstream\n\nendstream\nendobj\n",
+		},
+		{
+			name:  "Indexed melingkar",
+			img:   flateImage(1, 1, "/ColorSpace 9 0 R /BitsPerComponent 8", []byte{0}),
+			extra: "9 0 obj\n[/Indexed [/ICCBased 10 0 R] 1 <0000>]\nendobj\n10 0 obj\n<< /N 2 /Alternate 9 0 R /Length 0 >>\nstream\n\nendstream\nendobj\n",
+		},
+		{name: "stream mengembang", img: flateImage(2048, 1024, "/ColorSpace /DeviceGray /BitsPerComponent 8", make([]byte, 2048*1024))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append(buildPDF(tt.img), tt.extra...)
+			doc, err := parsePDF(data)
+			if err != nil {
+				t.Fatalf("parsePDF: %v", err)
+			}
+			s, ok := doc.resolve(pdfRef{num: 5}).(*pdfStream)
+			if !ok {
+				t.Fatalf("image XObject tidak ditemukan")
+			}
+			if _, err := doc.decodeImage(s); err == nil {
+				t.Fatal("decodeImage tidak mengembalikan error")
+			}
+		})
+	}
+}
+
+func TestParsePDFErrors(t *testing.T) {
+	if _, err := parsePDF([]byte("bukan pdf")); err == nil {
+		t.Fatal("data bukan PDF diterima")
+	}
+	encrypted := append(buildPDF(), []byte("trailer\n<< /Root 1 0 R /Encrypt 9 0 R >>\n")...)
+	if _, err := parsePDF(encrypted); !errors.Is(err, errPDFEncrypted) {
+		t.Fatalf("error %v, ingin %v", err, errPDFEncrypted)
+	}
+}
+
+func TestPDFParserObjects(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"42", 42},
+		{"-1.5", -1.5},
+		{"12 0 R", pdfRef{num: 12}},
+		{"/A#20B", pdfName("A B")},
+		{"(a\\(b\\)\\101)", "a(b)A"},
+		{"<48 69>", "Hi"},
+		{"<4>", "@"},
+		{"true", true},
+		{"null", nil},
+	}
+	for _, tt := range tests {
+		p := &pdfParser{data: []byte(tt.in)}
+		if got := p.object(); got != tt.want || p.err != nil {
+			t.Errorf("object(%q) = %#v (err %v), ingin %#v", tt.in, got, p.err, tt.want)
+		}
+	}
+	for _, in := range []string{"<< /A 1", "[1 2", "(abc", "<abc", "<< 1 2 >>", ")"} {
+		p := &pdfParser{data: []byte(in)}
+		if p.object(); p.err == nil {
+			t.Errorf("object(%q): error diharapkan", in)
+		}
+	}
+}
+
+// FuzzDecodeImage: parameter image XObject sembarang tidak boleh membuat panic.
+func FuzzDecodeImage(f *testing.F) {
+	f.Add(8, 8, 8, 1, false, []byte{1, 2, 3})
+	f.Add(3, 2, 16, 3, false, bytes.Repeat([]byte{0xFF}, 36))
+	f.Add(5, 5, 3, 1, false, make([]byte, 10))
+	f.Add(9, 1, 1, 0, true, []byte{0xAA, 0x55})
+	f.Add(4, 4, 2, 2, false, make([]byte, 4))
+	f.Fuzz(func(t *testing.T, w, h, bpc, space int, mask bool, data []byte) {
+		spaces := []string{
+			"/DeviceGray", "/DeviceRGB", "/DeviceCMYK",
+			"[/Indexed /DeviceRGB 3 <000000FF0000>]", "/Pattern",
+		}
+		dict := fmt.Sprintf("/Width %d /Height %d /BitsPerComponent %d /ColorSpace %s", w, h, bpc, spaces[uint(space)%uint(len(spaces))])
+		if mask {
+			dict += " /ImageMask true"
+		}
+		doc := &pdfFile{objects: map[int]any{}}
+		p := &pdfParser{data: []byte("<<" + dict + " /Filter /FlateDecode>>")}
+		d, ok := p.object().(pdfDict)
+		if !ok {
+			t.Skip()
+		}
+		doc.decodeImage(&pdfStream{dict: d, raw: flate(data)})
+	})
+}
+
+// FuzzParsePDF: file PDF rusak tidak boleh membuat panic di parser maupun
+// saat gambar halamannya di-decode.
+func FuzzParsePDF(f *testing.F) {
+	f.Add(buildPDF(flateImage(80, 80, "/ColorSpace /DeviceGray /BitsPerComponent 8", make([]byte, 80*80))))
+	f.Add(buildPDF(flateImage(80, 80, "/ColorSpace /DeviceRGB /BitsPerComponent 16 /DecodeParms << /Predictor 15 /Colors 3 /BitsPerComponent 16 /Columns 80 >>", make([]byte, 10))))
+	f.Add(buildPDF(flateImage(80, 80, "/ColorSpace [/Indexed /DeviceRGB 1 <000000FFFFFF>] /BitsPerComponent 1", make([]byte, 800))))
+	f.Add([]byte("%PDF-1.7\n1 0 obj << /Type /ObjStm /N 1 /First 4 >> stream\n2 0 << /Type /Catalog >>\nendstream endobj"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := parsePDF(data)
+		if err != nil {
+			return
+		}
+		images, _ := doc.pageImages()
+		for _, img := range images {
+			if !img.jpeg {
+				doc.decodeImage(img.stream)
+			}
+		}
+	})
+}
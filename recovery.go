@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"gorm.io/gorm"
+)
+
+// --- RECOVERY KEY ---
+//
+// Recovery key = 24 kata (BIP39, 256 bit entropi), ditampilkan SEKALI saat
+// master password pertama dibuat (atau lewat RegenerateRecoveryKey).
+//
+// Dari entropinya diturunkan pasangan kunci X25519. Yang disimpan di
+// GlobalConfig hanya public key ("recovery_pub") dan DEK vault yang
+// dienkripsi ke public key itu ("recovery_box"). Karena cukup public key,
+// DEK baru hasil rotasi bisa langsung dibungkus ulang tanpa recovery key.
+// Selama rotasi berjalan, DEK lama ikut disimpan di "recovery_rotation_box".
+
+const recoveryWords = 24
+
+type recoveryBox struct {
+	Ephemeral string `json:"eph"`    // public key ephemeral (base64)
+	Sealed    string `json:"sealed"` // nonce || ciphertext (base64)
+}
+
+func recoveryPrivateKey(entropy []byte) (*ecdh.PrivateKey, error) {
+	seed := sha256.Sum256(append([]byte("GalleryVault recovery key"), entropy...))
+	return ecdh.X25519().NewPrivateKey(seed[:])
+}
+
+func recoveryBoxKey(shared, ephPub, pub []byte) []byte {
+	h := sha256.New()
+	h.Write(shared)
+	h.Write(ephPub)
+	h.Write(pub)
+	return h.Sum(nil)
+}
+
+func sealRecoveryBox(pub *ecdh.PublicKey, dek []byte) (string, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	shared, err := eph.ECDH(pub)
+	if err != nil {
+		return "", err
+	}
+	key := recoveryBoxKey(shared, eph.PublicKey().Bytes(), pub.Bytes())
+	defer wipeBytes(key)
+	sealed, err := sealGCM(key, dek)
+	if err != nil {
+		return "", err
+	}
+	raw, _ := json.Marshal(recoveryBox{
+		Ephemeral: base64.StdEncoding.EncodeToString(eph.PublicKey().Bytes()),
+		Sealed:    base64.StdEncoding.EncodeToString(sealed),
+	})
+	return string(raw), nil
+}
+
+func openRecoveryBox(priv *ecdh.PrivateKey, raw string) ([]byte, error) {
+	var box recoveryBox
+	if err := json.Unmarshal([]byte(raw), &box); err != nil {
+		return nil, fmt.Errorf("recovery box rusak: %w", err)
+	}
+	ephRaw, err := base64.StdEncoding.DecodeString(box.Ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("recovery box rusak: %w", err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(box.Sealed)
+	if err != nil {
+		return nil, fmt.Errorf("recovery box rusak: %w", err)
+	}
+	eph, err := ecdh.X25519().NewPublicKey(ephRaw)
+	if err != nil {
+		return nil, fmt.Errorf("recovery box rusak: %w", err)
+	}
+	shared, err := priv.ECDH(eph)
+	if err != nil {
+		return nil, err
+	}
+	key := recoveryBoxKey(shared, ephRaw, priv.PublicKey().Bytes())
+	defer wipeBytes(key)
+	dek, err := openGCM(key, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("recovery key salah")
+	}
+	return dek, nil
+}
+
+// recoveryBoxFor mengenkripsi dek ke recovery public key profil aktif.
+// Mengembalikan "" jika recovery key belum pernah dibuat.
+func (a *App) recoveryBoxFor(dek []byte) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(a.getConfig(a.cfg("recovery_pub")))
+	if err != nil || len(raw) == 0 {
+		return "", nil
+	}
+	pub, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return "", fmt.Errorf("recovery public key rusak: %w", err)
+	}
+	return sealRecoveryBox(pub, dek)
+}
+
+// newRecoveryKey membuat recovery key baru untuk dek (recovery key lama
+// otomatis tidak berlaku lagi).
+func (a *App) newRecoveryKey(dek []byte) (string, error) {
+	entropy := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, entropy); err != nil {
+		return "", err
+	}
+	defer wipeBytes(entropy)
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", err
+	}
+	priv, err := recoveryPrivateKey(entropy)
+	if err != nil {
+		return "", err
+	}
+	box, err := sealRecoveryBox(priv.PublicKey(), dek)
+	if err != nil {
+		return "", err
+	}
+	err = a.configDB.Transaction(func(tx *gorm.DB) error {
+		if err := setConfigTx(tx, a.cfg("recovery_pub"), base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes())); err != nil {
+			return err
+		}
+		return setConfigTx(tx, a.cfg("recovery_box"), box)
+	})
+	if err != nil {
+		return "", err
+	}
+	return mnemonic, nil
+}
+
+func (a *App) HasRecoveryKey() bool {
+	return a.getConfig(a.cfg("recovery_pub")) != ""
+}
+
+// RegenerateRecoveryKey membuat recovery key baru (vault harus terbuka).
+// Recovery key lama langsung tidak berlaku.
+func (a *App) RegenerateRecoveryKey() (string, error) {
+	key := a.sessionKey()
+	if key == nil {
+		return "", ErrVaultLocked
+	}
+	defer wipeBytes(key)
+	if a.isRotating() {
+		return "", fmt.Errorf("rotasi kunci sedang berjalan")
+	}
+	mnemonic, err := a.newRecoveryKey(key)
+	a.audit(auditRecoveryKey, "master", err == nil, auditDetail(err))
+	return mnemonic, err
+}
+
+// RecoverWithKey membuka DEK dengan recovery key, membungkusnya ulang
+// dengan password baru, lalu membuka vault.
+func (a *App) RecoverWithKey(recoveryKey, newPassword string) error {
+	if newPassword == "" {
+		return fmt.Errorf("password baru tidak boleh kosong")
+	}
+	if a.retryAfter("recovery") > 0 {
+		a.audit(auditRecover, "master", false, "throttled")
+		return fmt.Errorf("terlalu banyak percobaan, coba lagi nanti")
+	}
+	dek, oldDEK, decoy, err := a.openWithRecoveryKey(recoveryKey)
+	a.recordAttempt("recovery", err == nil)
+	a.audit(auditRecover, "master", err == nil, auditDetail(err))
+	if err != nil {
+		return err
+	}
+	if a.usedByOtherProfile(newPassword, decoy) {
+		return errPasswordInUse
+	}
+
+	if a.IsVaultUnlocked() {
+		a.wipeSession()
+	}
+	a.useProfile(decoy)
+
+	masterSlot, err := newKeySlot(newPassword, dek)
+	if err != nil {
+		return err
+	}
+	var rotationSlot *keySlot
+	if oldDEK != nil {
+		// Rotasi yang belum selesai: DEK lama juga dibungkus password baru
+		if rotationSlot, err = newKeySlot(newPassword, oldDEK); err != nil {
+			return err
+		}
+	}
+	err = a.configDB.Transaction(func(tx *gorm.DB) error {
+		if rotationSlot != nil {
+			if err := setConfigTx(tx, a.cfg("rotation_keyslot"), marshalKeySlot(rotationSlot)); err != nil {
+				return err
+			}
+		}
+		if err := setConfigTx(tx, a.cfg("master_keyslot"), marshalKeySlot(masterSlot)); err != nil {
+			return err
+		}
+		return setConfigTx(tx, a.cfg("master_hash"), HashPassword(newPassword))
+	})
+	if err != nil {
+		return err
+	}
+	a.recordAttempt("master", true)
+
+	if !a.unlockSession(newPassword, dek) {
+		return fmt.Errorf("gagal membuka vault")
+	}
+	return nil
+}
+
+// openWithRecoveryKey mencocokkan recovery key dengan profil asli / decoy.
+// oldDEK != nil jika ada rotasi kunci yang belum selesai.
+func (a *App) openWithRecoveryKey(recoveryKey string) (dek, oldDEK []byte, decoy bool, err error) {
+	words := strings.Fields(strings.ToLower(recoveryKey))
+	if len(words) != recoveryWords {
+		return nil, nil, false, fmt.Errorf("recovery key harus %d kata", recoveryWords)
+	}
+	entropy, err := bip39.EntropyFromMnemonic(strings.Join(words, " "))
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("recovery key tidak valid")
+	}
+	defer wipeBytes(entropy)
+	priv, err := recoveryPrivateKey(entropy)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	pub := base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes())
+	prefix := ""
+	switch pub {
+	case a.getConfig("recovery_pub"):
+	case a.getConfig(decoyPrefix + "recovery_pub"):
+		prefix, decoy = decoyPrefix, true
+	default:
+		return nil, nil, false, fmt.Errorf("recovery key salah")
+	}
+
+	dek, err = openRecoveryBox(priv, a.getConfig(prefix+"recovery_box"))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if raw := a.getConfig(prefix + "recovery_rotation_box"); raw != "" {
+		if oldDEK, err = openRecoveryBox(priv, raw); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	return dek, oldDEK, decoy, nil
+}
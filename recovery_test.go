@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Lupa password: recovery key (24 kata) dari setup pertama membuka DEK yang
+// sama, dan password baru yang dipasang saat recovery membuka vault.
+func TestRecoverWithKey(t *testing.T) {
+	dir := t.TempDir()
+	a := startTestApp(t, dir)
+	mnemonic, err := a.SetMasterPassword("lama")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words := strings.Fields(mnemonic); len(words) != recoveryWords || !bip39.IsMnemonicValid(mnemonic) {
+		t.Fatalf("recovery key bukan BIP39 %d kata: %q", recoveryWords, mnemonic)
+	}
+	dek := bytes.Clone(a.sessionKey())
+	page := filepath.Join(a.vaultDir, "001.jpg")
+	data, err := EncryptData(dek, []byte("halaman"), "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(page, data, 0644)
+	a.lockSession("test")
+
+	b := startTestApp(t, dir)
+	otherKey, _ := bip39.NewMnemonic(make([]byte, 32))
+	words := strings.Fields(mnemonic)
+	for name, key := range map[string]string{
+		"jumlah kata kurang": strings.Join(words[1:], " "),
+		"checksum salah":     strings.Join(append(words[1:], words[0]), " "),
+		"recovery key lain":  otherKey,
+	} {
+		if err := b.RecoverWithKey(key, "baru"); err == nil {
+			t.Errorf("%s: recovery diterima", name)
+		}
+		b.recordAttempt("recovery", true) // reset throttling antar kasus
+	}
+	if b.IsVaultUnlocked() {
+		t.Fatal("vault terbuka dengan recovery key yang salah")
+	}
+
+	// Huruf besar & spasi berlebih tetap diterima
+	if err := b.RecoverWithKey("  "+strings.ToUpper(mnemonic)+"\n", "baru"); err != nil {
+		t.Fatalf("RecoverWithKey: %v", err)
+	}
+	if !bytes.Equal(b.sessionKey(), dek) {
+		t.Fatal("recovery membuka DEK yang berbeda")
+	}
+	b.lockSession("test")
+
+	c := startTestApp(t, dir)
+	if c.VerifyPassword("lama") {
+		t.Error("password lama masih diterima setelah recovery")
+	}
+	if !c.VerifyPassword("baru") {
+		t.Fatal("password baru ditolak setelah recovery")
+	}
+	data, _ = os.ReadFile(page)
+	if got, _, err := DecryptData(data, c.sessionKey()); err != nil || string(got) != "halaman" {
+		t.Fatalf("file vault tidak terbaca setelah recovery: %v", err)
+	}
+}
+
+// Recovery tidak boleh memasang password profil lain sebagai password baru
+// (sama seperti SetMasterPassword), dan profil decoy tidak melihat adanya
+// decoy.
+func TestRecoverWithKeyOtherProfilePassword(t *testing.T) {
+	dir := t.TempDir()
+	a := startTestApp(t, dir)
+	mnemonic, err := a.SetMasterPassword("asli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetDecoyPassword("lain"); err != nil {
+		t.Fatal(err)
+	}
+	if !a.HasDecoyPassword() {
+		t.Fatal("HasDecoyPassword = false di profil asli")
+	}
+	a.lockSession("test")
+
+	b := startTestApp(t, dir)
+	if err := b.RecoverWithKey(mnemonic, "lain"); !errors.Is(err, errPasswordInUse) {
+		t.Fatalf("RecoverWithKey dengan password decoy = %v, ingin %v", err, errPasswordInUse)
+	}
+	if b.IsVaultUnlocked() {
+		t.Fatal("vault terbuka setelah recovery ditolak")
+	}
+	if !b.VerifyPassword("lain") {
+		t.Fatal("password decoy ditolak")
+	}
+	if b.HasDecoyPassword() {
+		t.Error("HasDecoyPassword = true dari dalam decoy")
+	}
+	b.lockSession("test")
+
+	c := startTestApp(t, dir)
+	if !c.VerifyPassword("asli") {
+		t.Fatal("password asli ditolak setelah recovery yang gagal")
+	}
+}
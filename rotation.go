@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// --- KEY ROTATION & MIGRASI VAULT LEGACY ---
+//
+// Alur rotasi:
+//  1. DEK lama dibungkus dengan password BARU ke "rotation_keyslot",
+//     lalu master_keyslot diganti dengan DEK baru (satu transaksi).
+//  2. Worker menelusuri vault dan meng-enkripsi ulang file satu per satu
+//     (tulis ke .tmp lalu rename, jadi atomic per file).
+//  3. Setelah selesai, "rotation_keyslot" dihapus.
+//
+// Jika aplikasi ditutup di tengah jalan, VerifyPassword berikutnya akan
+// membuka "rotation_keyslot" dan melanjutkan. File yang sudah memakai DEK
+// baru dilewati, jadi proses aman diulang.
+
+const tmpFileSuffix = ".gv.tmp"
+
+type RotationProgress struct {
+	Done    int    `json:"done"`
+	Total   int    `json:"total"`
+	Failed  int    `json:"failed"`
+	Current string `json:"current"`
+}
+
+// RotateVaultKey mengganti DEK vault dan (opsional) password master.
+// oldPassword dan newPassword boleh sama (rotasi kunci saja).
+func (a *App) RotateVaultKey(oldPassword, newPassword string) error {
+	if newPassword == "" {
+		return fmt.Errorf("password baru tidak boleh kosong")
+	}
+	if a.isRotating() {
+		return fmt.Errorf("rotasi kunci sedang berjalan")
+	}
+	// Library harus terbuka: library.vault hanya ditulis ulang dengan DEK
+	// baru lewat flush, DEK lama dibuang setelah rotasi selesai
+	if !a.IsVaultUnlocked() || !a.isLibraryOpen() {
+		return ErrVaultLocked
+	}
+	if !a.checkConfigPassword("master", a.cfg("master_hash"), oldPassword) {
+		a.audit(auditKeyRotation, "master", false, auditDetail(ErrWrongPassword))
+		return ErrWrongPassword
+	}
+	slot := a.loadKeySlot(a.cfg("master_keyslot"))
+	if slot == nil {
+		return fmt.Errorf("vault belum pernah dibuka, login dulu")
+	}
+	oldKey, err := slot.unwrap(oldPassword)
+	if err != nil {
+		return err
+	}
+	newKey, err := newDataKey()
+	if err != nil {
+		return err
+	}
+
+	retiredSlot, err := newKeySlot(newPassword, oldKey)
+	if err != nil {
+		return err
+	}
+	masterSlot, err := newKeySlot(newPassword, newKey)
+	if err != nil {
+		return err
+	}
+	// Recovery key tetap berlaku: DEK baru (dan DEK lama selama rotasi)
+	// dibungkus ulang ke recovery public key
+	recoveryBox, err := a.recoveryBoxFor(newKey)
+	if err != nil {
+		return err
+	}
+	recoveryRotationBox, err := a.recoveryBoxFor(oldKey)
+	if err != nil {
+		return err
+	}
+	err = a.configDB.Transaction(func(tx *gorm.DB) error {
+		if err := setConfigTx(tx, a.cfg("rotation_keyslot"), marshalKeySlot(retiredSlot)); err != nil {
+			return err
+		}
+		if recoveryBox != "" {
+			if err := setConfigTx(tx, a.cfg("recovery_box"), recoveryBox); err != nil {
+				return err
+			}
+			if err := setConfigTx(tx, a.cfg("recovery_rotation_box"), recoveryRotationBox); err != nil {
+				return err
+			}
+		}
+		if err := setConfigTx(tx, a.cfg("master_keyslot"), marshalKeySlot(masterSlot)); err != nil {
+			return err
+		}
+		return setConfigTx(tx, a.cfg("master_hash"), HashPassword(newPassword))
+	})
+	if err != nil {
+		return err
+	}
+
+	a.setSessionKey(newKey)
+	a.keyMu.Lock()
+	a.retiredKey = oldKey
+	a.keyMu.Unlock()
+	// Library langsung ditulis ulang dengan kunci baru
+	if err := a.flushLibrary(); err != nil {
+		log.Printf("library: gagal menyimpan: %v", err)
+	}
+	a.audit(auditKeyRotation, "master", true, "")
+	a.startRotation(oldKey, newKey)
+	return nil
+}
+
+// pendingRotationKey membuka DEK lama dari rotasi yang terputus (jika ada)
+// supaya file & library yang belum dirotasi tetap terbaca.
+func (a *App) pendingRotationKey(password string) []byte {
+	slot := a.loadKeySlot(a.cfg("rotation_keyslot"))
+	if slot == nil {
+		return nil
+	}
+	oldKey, err := slot.unwrap(password)
+	if err != nil {
+		// Slot TIDAK dihapus: file yang belum dirotasi hanya bisa dibuka
+		// dengan DEK ini (masih bisa lewat recovery key)
+		log.Printf("rotasi: kunci lama tidak bisa dibuka: %v", err)
+		return nil
+	}
+	a.keyMu.Lock()
+	a.retiredKey = oldKey
+	a.keyMu.Unlock()
+	return oldKey
+}
+
+// resumeRotation dipanggil setelah unlock berhasil. Melanjutkan rotasi yang
+// terputus, atau memigrasi file yang masih memakai kunci legacy.
+func (a *App) resumeRotation(oldKey, key []byte) {
+	if oldKey != nil {
+		a.startRotation(oldKey, key)
+		return
+	}
+	if a.getConfig(a.cfg("legacy_vault")) != "" {
+		// Migrasi: kunci "lama" = kunci legacy, sudah otomatis dicoba
+		a.startRotation(nil, key)
+	}
+}
+
+func (a *App) isRotating() bool {
+	a.keyMu.RLock()
+	defer a.keyMu.RUnlock()
+	return a.rotating
+}
+
+func (a *App) IsRotatingVaultKey() bool { return a.isRotating() }
+
+func (a *App) startRotation(oldKey, newKey []byte) {
+	a.keyMu.Lock()
+	if a.rotating {
+		a.keyMu.Unlock()
+		return
+	}
+	a.rotating = true
+	a.retiredKey = oldKey
+	ctx, cancel := context.WithCancel(context.Background())
+	a.rotationCancel = cancel
+	prevDone := a.rotationDone
+	done := make(chan struct{})
+	a.rotationDone = done
+	a.keyMu.Unlock()
+
+	// Salinan sendiri: kunci sesi bisa di-wipe saat vault dikunci
+	newKey = append([]byte(nil), newKey...)
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		if prevDone != nil {
+			<-prevDone // rotasi sesi sebelumnya masih menyelesaikan satu file
+		}
+		progress := a.rotateVaultFiles(ctx, oldKey, newKey)
+		wipeBytes(newKey)
+
+		a.keyMu.Lock()
+		if ctx.Err() != nil {
+			// Sesi dikunci (wipeSession sudah menghapus kunci & status
+			// rotasi); rotation_keyslot tetap ada, dilanjutkan saat unlock
+			a.keyMu.Unlock()
+			return
+		}
+		a.rotating = false
+		a.rotationCancel = nil
+		if progress.Failed == 0 {
+			a.retiredKey = nil
+		}
+		a.keyMu.Unlock()
+		// Ada file yang gagal: DEK lama tetap di memori (file itu masih
+		// terbaca, dan SetMasterPassword bisa membungkus ulang rotation_keyslot)
+		if oldKey != nil && progress.Failed == 0 {
+			wipeBytes(oldKey)
+		}
+
+		if progress.Failed == 0 {
+			a.deleteConfig(a.cfg("rotation_keyslot"))
+			a.deleteConfig(a.cfg("recovery_rotation_box"))
+			a.deleteConfig(a.cfg("legacy_vault"))
+		}
+		a.emit("vault:rotation_done", progress)
+	}()
+}
+
+func (a *App) rotateVaultFiles(ctx context.Context, oldKey, newKey []byte) RotationProgress {
+	// Buku terkunci yang sudah tersegel memakai kunci bukunya sendiri, tidak
+	// ikut dirotasi. Buku yang enkripsi ulangnya belum selesai (CryptoPending)
+	// masih punya file dengan kunci vault: file itu ikut dirotasi, file yang
+	// sudah memakai kunci buku dilewati.
+	sealedDirs := map[string]bool{}
+	pendingDirs := map[string]bool{}
+	var locked []Book
+	a.libraryDB().Where("is_locked = ? AND key_slot <> ''", true).Find(&locked)
+	for _, b := range locked {
+		if b.CryptoPending {
+			pendingDirs[filepath.Clean(b.Path)] = true
+		} else {
+			sealedDirs[filepath.Clean(b.Path)] = true
+		}
+	}
+
+	var files []string
+	pendingFiles := map[string]bool{}
+	var pendingRoot string
+	filepath.WalkDir(a.vaultDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			clean := filepath.Clean(path)
+			if sealedDirs[clean] {
+				return filepath.SkipDir
+			}
+			if pendingDirs[clean] {
+				pendingRoot = clean + string(filepath.Separator)
+			} else if pendingRoot != "" && !strings.HasPrefix(clean, pendingRoot) {
+				pendingRoot = ""
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, tmpFileSuffix) {
+			os.Remove(path) // sisa rotasi yang terputus
+			return nil
+		}
+		files = append(files, path)
+		if pendingRoot != "" && strings.HasPrefix(path, pendingRoot) {
+			pendingFiles[path] = true
+		}
+		return nil
+	})
+
+	progress := RotationProgress{Total: len(files)}
+	for _, path := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		rel, _ := filepath.Rel(a.vaultDir, path)
+		progress.Current = filepath.ToSlash(rel)
+		err := reencryptFile(path, newKey, oldKey)
+		if errors.Is(err, ErrWrongKey) && pendingFiles[path] {
+			err = nil // sudah memakai kunci buku
+		}
+		if err != nil {
+			log.Printf("rotasi gagal [%s]: %v", rel, err)
+			progress.Failed++
+		}
+		progress.Done++
+		a.emit("vault:rotation", progress)
+	}
+	return progress
+}
+
+// reencryptFile meng-enkripsi ulang satu file ke newKey secara atomic.
+// File yang sudah memakai newKey (format terbaru) dilewati. File format
+// lama ikut di-upgrade ke container v2, dan file polos ikut dienkripsi.
+func reencryptFile(path string, newKey []byte, oldKeys ...[]byte) error {
+	vf, err := openVaultFile(path, append([][]byte{newKey}, oldKeys...)...)
+	if err != nil && !errors.Is(err, ErrPlaintextFile) {
+		return err
+	}
+	defer vf.Close()
+
+	h := vf.Header
+	if h.Version == containerV2 && h.KeyID == keyID(newKey) {
+		return nil
+	}
+	return writeEncryptedFile(path, newKey, h.MIME, int64(h.PlainLen), vf)
+}
+
+// writeFileAtomic menulis ke file sementara lalu rename ke path tujuan.
+func writeFileAtomic(path string, data []byte) error {
+	return writeFileAtomicFunc(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func writeFileAtomicFunc(path string, write func(w io.Writer) error) error {
+	tmp := path + tmpFileSuffix
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitRotation menunggu rotasi kunci di background selesai.
+func waitRotation(t *testing.T, a *App) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for a.isRotating() {
+		if time.Now().After(deadline) {
+			t.Fatal("rotasi kunci tidak selesai")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Rotasi terputus setelah keyslot diganti dan sebagian file dienkripsi
+// ulang: unlock berikutnya melanjutkan rotasi sampai semua file memakai
+// DEK baru.
+func TestRotationResumeAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	a := startTestApp(t, dir)
+	if _, err := a.SetMasterPassword("lama"); err != nil {
+		t.Fatal(err)
+	}
+	oldKey := append([]byte(nil), a.sessionKey()...)
+
+	bookDir := filepath.Join(a.vaultDir, "Buku")
+	os.MkdirAll(bookDir, 0755)
+	var files []string
+	plain := map[string][]byte{}
+	for i := 1; i <= 6; i++ {
+		path := filepath.Join(bookDir, fmt.Sprintf("%03d.jpg", i))
+		plain[path] = []byte(fmt.Sprintf("halaman %d", i))
+		data, err := EncryptData(oldKey, plain[path], "image/jpeg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(path, data, 0644)
+		files = append(files, path)
+	}
+
+	// Langkah 1 rotasi sudah tersimpan, lalu aplikasi mati setelah separuh
+	// file dienkripsi ulang (plus satu file sementara yang setengah jadi)
+	newKey, err := newDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	retiredSlot, _ := newKeySlot("baru", oldKey)
+	masterSlot, _ := newKeySlot("baru", newKey)
+	a.saveKeySlot("rotation_keyslot", retiredSlot)
+	a.saveKeySlot("master_keyslot", masterSlot)
+	a.setConfig("master_hash", HashString("baru"))
+	for _, path := range files[:3] {
+		if err := reencryptFile(path, newKey, oldKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(files[3]+tmpFileSuffix, []byte("setengah"), 0644)
+
+	b := startTestApp(t, dir)
+	if b.VerifyPassword("lama") {
+		t.Fatal("password lama masih diterima")
+	}
+	if !b.VerifyPassword("baru") {
+		t.Fatal("password baru ditolak")
+	}
+	waitRotation(t, b)
+
+	if !bytes.Equal(b.sessionKey(), newKey) {
+		t.Fatal("kunci sesi bukan DEK baru")
+	}
+	for _, path := range files {
+		data, _ := os.ReadFile(path)
+		got, _, err := DecryptData(data, newKey)
+		if err != nil {
+			t.Errorf("%s tidak terbuka dengan DEK baru: %v", filepath.Base(path), err)
+			continue
+		}
+		if !bytes.Equal(got, plain[path]) {
+			t.Errorf("%s: isi berbeda setelah rotasi", filepath.Base(path))
+		}
+	}
+	if _, err := os.Stat(files[3] + tmpFileSuffix); !os.IsNotExist(err) {
+		t.Error("file sementara rotasi tidak dihapus")
+	}
+	if b.getConfig("rotation_keyslot") != "" {
+		t.Error("rotation_keyslot tidak dihapus setelah rotasi selesai")
+	}
+}
+
+// Rotasi ditolak selama vault terkunci: tidak ada keyslot yang diganti.
+func TestRotateVaultKeyLocked(t *testing.T) {
+	a := startTestApp(t, t.TempDir())
+	if _, err := a.SetMasterPassword("lama"); err != nil {
+		t.Fatal(err)
+	}
+	a.lockSession("test")
+	slot := a.getConfig("master_keyslot")
+
+	if err := a.RotateVaultKey("lama", "baru"); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("RotateVaultKey = %v, ingin %v", err, ErrVaultLocked)
+	}
+	if a.getConfig("master_keyslot") != slot || a.getConfig("rotation_keyslot") != "" {
+		t.Fatal("keyslot berubah padahal rotasi ditolak")
+	}
+	if !a.VerifyPassword("lama") {
+		t.Fatal("password lama ditolak setelah rotasi yang gagal")
+	}
+}
+
+// Kunci baca yang dikembalikan ke pemanggil adalah salinan: menghapusnya
+// tidak merusak kunci sesi, kunci lama rotasi, atau kunci buku.
+func TestReadKeysAreCopies(t *testing.T) {
+	a := newTestApp(t)
+	session := a.sessionKey()
+	retired := testKey(t)
+	a.keyMu.Lock()
+	a.retiredKey = bytes.Clone(retired)
+	a.keyMu.Unlock()
+	book := addTestBook(t, a, Book{Title: "Terkunci", IsLocked: true, KeySlot: "{}"})
+	bookKey := testKey(t)
+	a.setBookKey(book.ID, bytes.Clone(bookKey))
+
+	wipeKeys(a.bookReadKeys(book))
+
+	keys := a.bookReadKeys(book)
+	defer wipeKeys(keys)
+	want := [][]byte{bookKey, session, retired}
+	if len(keys) != len(want) {
+		t.Fatalf("%d kunci, ingin %d", len(keys), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(keys[i], want[i]) {
+			t.Errorf("kunci %d berubah setelah salinannya dihapus", i)
+		}
+	}
+}
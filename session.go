@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// --- SESSION MANAGER (AUTO-LOCK) ---
+//
+// Setelah vault terbuka, sesi otomatis dikunci jika tidak ada aktivitas
+// selama IdleMinutes (frontend memanggil ReportActivity), atau langsung
+// saat jendela di-minimize / kehilangan fokus jika LockOnBlur aktif.
+//
+// Mengunci sesi = simpan & tutup library, matikan Hidden Zone, hapus kunci
+// semua buku yang terbuka dan kunci vault dari memori, lalu kirim event
+// "vault:locked" supaya frontend kembali ke layar login.
+//
+// Selama ada proses yang memakai kunci (import, rotasi), auto-lock ditunda.
+
+const (
+	configIdleMinutes  = "idle_timeout_minutes"
+	configLockOnBlur   = "lock_on_blur"
+	defaultIdleMinutes = 10
+	maxIdleMinutes     = 24 * 60
+)
+
+type SessionSettings struct {
+	IdleMinutes int  `json:"idle_minutes"` // 0 = tidak pernah
+	LockOnBlur  bool `json:"lock_on_blur"`
+}
+
+func (a *App) GetSessionSettings() SessionSettings {
+	s := SessionSettings{IdleMinutes: defaultIdleMinutes}
+	if raw := a.getConfig(configIdleMinutes); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil {
+			s.IdleMinutes = n
+		}
+	}
+	s.LockOnBlur = a.getConfig(configLockOnBlur) == "1"
+	return s
+}
+
+func (a *App) SetSessionSettings(s SessionSettings) error {
+	if s.IdleMinutes < 0 || s.IdleMinutes > maxIdleMinutes {
+		return fmt.Errorf("timeout harus antara 0 dan %d menit", maxIdleMinutes)
+	}
+	a.setConfig(configIdleMinutes, strconv.Itoa(s.IdleMinutes))
+	if s.LockOnBlur {
+		a.setConfig(configLockOnBlur, "1")
+	} else {
+		a.deleteConfig(configLockOnBlur)
+	}
+	a.armIdleTimer()
+	return nil
+}
+
+func (a *App) idleTimeout() time.Duration {
+	return time.Duration(a.GetSessionSettings().IdleMinutes) * time.Minute
+}
+
+// ReportActivity dipanggil frontend saat ada input user (di-throttle di sisi JS).
+func (a *App) ReportActivity() {
+	a.sessionMu.Lock()
+	a.lastActivity = time.Now()
+	a.sessionMu.Unlock()
+}
+
+// WindowHidden dipanggil frontend saat jendela di-minimize / kehilangan fokus.
+func (a *App) WindowHidden() {
+	if a.GetSessionSettings().LockOnBlur && !a.sessionBusy() {
+		a.lockSession("blur")
+	}
+}
+
+// LockVault: kunci manual dari tombol di frontend.
+func (a *App) LockVault() error {
+	if a.sessionBusy() {
+		return fmt.Errorf("masih ada proses yang berjalan, coba lagi nanti")
+	}
+	a.lockSession("manual")
+	return nil
+}
+
+// holdSession menandai proses yang sedang memakai kunci vault. Panggil
+// fungsi yang dikembalikan setelah selesai.
+func (a *App) holdSession() func() {
+	a.sessionMu.Lock()
+	a.sessionHolds++
+	a.sessionMu.Unlock()
+	return func() {
+		a.sessionMu.Lock()
+		a.sessionHolds--
+		a.lastActivity = time.Now()
+		a.sessionMu.Unlock()
+	}
+}
+
+func (a *App) sessionBusy() bool {
+	a.sessionMu.Lock()
+	holds := a.sessionHolds
+	a.sessionMu.Unlock()
+	return holds > 0 || a.isRotating()
+}
+
+// armIdleTimer (ulang) memasang timer idle sesuai pengaturan.
+func (a *App) armIdleTimer() {
+	timeout := a.idleTimeout()
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if a.idleTimer != nil {
+		a.idleTimer.Stop()
+		a.idleTimer = nil
+	}
+	if timeout <= 0 || !a.IsVaultUnlocked() {
+		return
+	}
+	a.lastActivity = time.Now()
+	a.idleTimer = time.AfterFunc(timeout, a.checkIdle)
+}
+
+func (a *App) checkIdle() {
+	timeout := a.idleTimeout()
+	busy := a.sessionBusy()
+	a.sessionMu.Lock()
+	idle := time.Since(a.lastActivity)
+	if timeout > 0 && (idle < timeout || busy) {
+		// Belum idle (atau masih ada proses): cek lagi nanti
+		a.idleTimer = time.AfterFunc(max(timeout-idle, time.Minute), a.checkIdle)
+		a.sessionMu.Unlock()
+		return
+	}
+	a.idleTimer = nil
+	a.sessionMu.Unlock()
+	if timeout > 0 {
+		a.lockSession("idle")
+	}
+}
+
+// lockSession mengunci semuanya. Aman dipanggil berkali-kali.
+func (a *App) lockSession(reason string) {
+	a.sessionMu.Lock()
+	if a.idleTimer != nil {
+		a.idleTimer.Stop()
+		a.idleTimer = nil
+	}
+	a.sessionMu.Unlock()
+
+	if !a.IsVaultUnlocked() {
+		return
+	}
+	// Dicatat sebelum library ditutup supaya masuk checkpoint profil
+	a.audit(auditSessionLock, "master", true, reason)
+	a.wipeSession()
+	log.Printf("sesi dikunci (%s)", reason)
+	a.emit("vault:locked", reason)
+}
+
+// wipeSession menutup library dan menghapus semua kunci dari memori.
+func (a *App) wipeSession() {
+	// Library disimpan dulu selagi kunci vault masih ada
+	if err := a.closeLibrary(); err != nil {
+		log.Printf("library: gagal menyimpan saat mengunci: %v", err)
+	}
+	a.hiddenModeActive = false
+
+	a.keyMu.Lock()
+	for id, key := range a.bookKeys {
+		wipeBytes(key)
+		delete(a.bookKeys, id)
+	}
+	// Rotasi berhenti setelah file yang sedang ditulis; DEK lama ikut dihapus
+	if a.rotationCancel != nil {
+		a.rotationCancel()
+		a.rotationCancel = nil
+	}
+	a.rotating = false
+	if a.retiredKey != nil {
+		wipeBytes(a.retiredKey)
+		a.retiredKey = nil
+	}
+	a.keyMu.Unlock()
+	a.setSessionKey(nil)
+
+	// Import yang berjalan tidak bisa lanjut tanpa kunci; jurnalnya sudah
+	// tersimpan di library, jadi dilanjutkan setelah unlock berikutnya
+	a.cancelImports()
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// --- METADATA SIDECAR (ComicInfo.xml / info.json) ---
+//
+// Folder atau arsip yang diimpor boleh membawa file metadata di root-nya
+// (atau di dalam satu folder pembungkus):
+//
+//	ComicInfo.xml  format ComicRack (dipakai Komga, Kavita, Mylar, ...)
+//	info.json      metadata dari gallery-dl
+//
+// Jika keduanya ada, ComicInfo.xml yang dipakai.
+
+const (
+	readingLTR = "ltr"
+	readingRTL = "rtl"
+)
+
+// sidecarFile: kandidat file metadata yang ditemukan saat scan.
+type sidecarFile struct {
+	Rel  string
+	Open func() (io.ReadCloser, error)
+}
+
+// sidecarRank: 0 = bukan sidecar; makin kecil makin diutamakan.
+func sidecarRank(rel string) int {
+	if strings.Count(rel, "/") > 1 {
+		return 0
+	}
+	switch strings.ToLower(path.Base(rel)) {
+	case "comicinfo.xml":
+		return 1
+	case "info.json":
+		return 2
+	}
+	return 0
+}
+
+// readSidecars membaca sidecar terbaik. Sidecar yang rusak dilewati.
+func readSidecars(files []sidecarFile) *bookMetadata {
+	best := -1
+	for i, f := range files {
+		if best < 0 || sidecarRank(f.Rel) < sidecarRank(files[best].Rel) ||
+			(sidecarRank(f.Rel) == sidecarRank(files[best].Rel) && len(f.Rel) < len(files[best].Rel)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	meta, err := readSidecar(files[best])
+	if err != nil {
+		return nil
+	}
+	return meta
+}
+
+func readSidecar(f sidecarFile) (*bookMetadata, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	lr := io.LimitReader(r, 4<<20)
+	if sidecarRank(f.Rel) == 1 {
+		return parseComicInfo(lr)
+	}
+	return parseInfoJSON(lr)
+}
+
+// ComicInfo.xml (schema ComicRack v2)
+type comicInfo struct {
+	Title       string
+	Series      string
+	Number      string
+	Volume      string
+	Summary     string
+	Writer      string
+	Penciller   string
+	Inker       string
+	CoverArtist string
+	Genre       string
+	Tags        string
+	LanguageISO string
+	Manga       string
+}
+
+func parseComicInfo(r io.Reader) (*bookMetadata, error) {
+	var ci comicInfo
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	if err := dec.Decode(&ci); err != nil {
+		return nil, fmt.Errorf("ComicInfo.xml tidak valid: %w", err)
+	}
+	meta := &bookMetadata{
+		Title:       strings.TrimSpace(ci.Title),
+		Series:      strings.TrimSpace(ci.Series),
+		Number:      strings.TrimSpace(ci.Number),
+		Description: strings.TrimSpace(ci.Summary),
+		Author:      joinNames(splitList(ci.Writer)),
+		Artist:      joinNames(splitList(ci.Penciller, ci.Inker, ci.CoverArtist)),
+		Language:    strings.TrimSpace(ci.LanguageISO),
+		Tags:        splitList(ci.Genre, ci.Tags),
+	}
+	meta.Volume, _ = strconv.Atoi(strings.TrimSpace(ci.Volume))
+	switch ci.Manga {
+	case "YesAndRightToLeft":
+		meta.Direction = readingRTL
+	case "No":
+		meta.Direction = readingLTR
+	}
+	if meta.Title == "" && meta.Series != "" {
+		meta.Title = meta.Series
+		if meta.Volume > 0 {
+			meta.Title += fmt.Sprintf(" Vol. %d", meta.Volume)
+		}
+		if meta.Number != "" {
+			meta.Title += " #" + meta.Number
+		}
+	}
+	return meta, nil
+}
+
+// parseInfoJSON membaca info.json gallery-dl. Nama field berbeda-beda per
+// situs, jadi beberapa nama umum dicoba. Tag bernamespace ("artist:xxx",
+// "language:xxx", "parody:xxx") dipindah ke field yang sesuai.
+func parseInfoJSON(r io.Reader) (*bookMetadata, error) {
+	var info map[string]any
+	if err := json.NewDecoder(r).Decode(&info); err != nil {
+		return nil, fmt.Errorf("info.json tidak valid: %w", err)
+	}
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := jsonStrings(info[k]); len(v) > 0 {
+				return v[0]
+			}
+		}
+		return ""
+	}
+	all := func(keys ...string) []string {
+		var out []string
+		for _, k := range keys {
+			out = append(out, jsonStrings(info[k])...)
+		}
+		return out
+	}
+
+	meta := &bookMetadata{
+		Title:       first("title", "title_en", "title_jpn"),
+		Description: first("description", "summary"),
+		Series:      first("series", "parody"),
+		Number:      first("chapter", "number"),
+		Language:    first("lang", "language"),
+	}
+	meta.Volume, _ = strconv.Atoi(first("volume"))
+	authors := all("author", "writer")
+	artists := all("artist", "artists")
+
+	for _, tag := range all("tags") {
+		ns, value, ok := strings.Cut(tag, ":")
+		if !ok {
+			meta.Tags = append(meta.Tags, tag)
+			continue
+		}
+		switch ns {
+		case "artist":
+			artists = append(artists, value)
+		case "language":
+			if meta.Language == "" && value != "translated" {
+				meta.Language = value
+			}
+		case "parody", "series":
+			if meta.Series == "" && value != "original" {
+				meta.Series = value
+			}
+		default:
+			meta.Tags = append(meta.Tags, tag)
+		}
+	}
+	meta.Author = joinNames(authors)
+	meta.Artist = joinNames(artists)
+	meta.Tags = splitList(meta.Tags...)
+	return meta, nil
+}
+
+// jsonStrings: nilai JSON (string, angka, atau list) menjadi []string.
+func jsonStrings(v any) []string {
+	switch x := v.(type) {
+	case string:
+		if s := strings.TrimSpace(x); s != "" {
+			return []string{s}
+		}
+	case float64:
+		return []string{strconv.FormatFloat(x, 'f', -1, 64)}
+	case []any:
+		var out []string
+		for _, item := range x {
+			out = append(out, jsonStrings(item)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// splitList memecah daftar "a, b, c" (bisa beberapa sekaligus), tanpa duplikat.
+func splitList(lists ...string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, list := range lists {
+		for _, item := range strings.Split(list, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" && !seen[strings.ToLower(item)] {
+				seen[strings.ToLower(item)] = true
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+func joinNames(names []string) string {
+	return strings.Join(splitList(names...), ", ")
+}
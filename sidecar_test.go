@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// importWithSidecar mengimpor folder halaman berisi satu file sidecar
+// dengan nama buku default (nama folder).
+func importWithSidecar(t *testing.T, a *App, name, content string, opts ImportOptions) (folder string, book *Book) {
+	t.Helper()
+	src := writeTestPages(t, 2)
+	os.WriteFile(filepath.Join(src, name), []byte(content), 0644)
+	report := a.importBook(context.Background(), newImportTracker(a, ""), filepath.Base(src), src, false, opts)
+	if report.Error != "" || report.Imported != 2 {
+		t.Fatalf("import: %+v", report)
+	}
+	book = &Book{}
+	if err := a.db.Preload("Tags").Preload("Series").Where("title = ?", report.Book).First(book).Error; err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Base(src), book
+}
+
+const comicInfoTraversal = `<?xml version="1.0"?>
+<ComicInfo>
+  <Title>../../Keluar</Title>
+  <Series>Seri</Series>
+  <Volume>2</Volume>
+  <Summary>Ringkasan</Summary>
+  <Writer>Penulis</Writer>
+  <Genre>Aksi, Drama</Genre>
+  <LanguageISO>id</LanguageISO>
+</ComicInfo>`
+
+func TestSidecarTitle(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		prefer              bool
+		wantTitle           string // "" = nama folder sumber
+	}{
+		{"ComicInfo, judul metadata", "ComicInfo.xml", comicInfoTraversal, true, "../../Keluar"},
+		{"ComicInfo, judul folder", "ComicInfo.xml", comicInfoTraversal, false, ""},
+		{"info.json, judul metadata", "info.json", `{"title": "/", "description": "Ringkasan", "series": "Seri", "volume": 2, "tags": ["Aksi", "Drama"], "author": "Penulis", "lang": "id"}`, true, "/"},
+		{"info.json, judul folder", "info.json", `{"title": "..", "description": "Ringkasan", "series": "Seri", "volume": 2, "tags": ["Aksi", "Drama"], "author": "Penulis", "lang": "id"}`, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t)
+			folder, book := importWithSidecar(t, a, tt.file, tt.content, ImportOptions{PreferSidecar: tt.prefer})
+			want := tt.wantTitle
+			if want == "" {
+				want = folder
+			}
+			if book.Title != want {
+				t.Errorf("judul = %q, want %q", book.Title, want)
+			}
+			if !a.inVault(book.Path) {
+				t.Errorf("folder buku %q di luar vault", book.Path)
+			}
+
+			// Field lain selalu dipakai, apa pun pilihan judulnya
+			if book.Description != "Ringkasan" || book.Author != "Penulis" || book.Volume != 2 || book.Language != "id" {
+				t.Errorf("metadata hilang: %+v", book)
+			}
+			if book.Series == nil || book.Series.Title != "Seri" {
+				t.Errorf("series = %+v, want Seri", book.Series)
+			}
+			if len(book.Tags) != 2 {
+				t.Errorf("tag = %v, want 2", book.Tags)
+			}
+		})
+	}
+}
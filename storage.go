@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/disintegration/imaging"
+)
+
+// --- PROFIL PENYIMPANAN HALAMAN ---
+//
+//	compressed  (default) diperkecil & JPEG, lihat ImageSettings.Compressed
+//	lossless    piksel asli disimpan sebagai PNG (resize hanya jika
+//	            ImageSettings.Lossless diberi batas ukuran)
+//	original    byte file sumber apa adanya (hanya dienkripsi). Format yang
+//	            tidak bisa ditampilkan webview (TIFF, HEIC) disimpan sebagai PNG
+//
+// GIF / WebP animasi selalu disimpan sebagai file asli (dengan MIME-nya di
+// header container), di profil mana pun, supaya animasinya tidak hilang.
+//
+// Profil dipilih per import dan dicatat di Book.StorageProfile. Sync ke
+// buku yang sudah ada selalu memakai profil bukunya, supaya isi satu buku
+// konsisten. Buku lama (kolom kosong) dianggap compressed.
+
+const (
+	storageCompressed = "compressed"
+	storageLossless   = "lossless"
+	storageOriginal   = "original"
+)
+
+func normalizeStorageProfile(profile string) (string, error) {
+	switch profile {
+	case "", storageCompressed:
+		return storageCompressed, nil
+	case storageLossless, storageOriginal:
+		return profile, nil
+	}
+	return "", fmt.Errorf("profil penyimpanan tidak dikenal: %s", profile)
+}
+
+// pageExt: ekstensi file halaman di vault untuk profil ini.
+func pageExt(profile string, page importPage) string {
+	if keepOriginal(profile, page) {
+		return page.Format.Exts[0]
+	}
+	switch profile {
+	case storageLossless, storageOriginal:
+		// original: piksel mentah (PDF) / format yang tidak tampil di webview
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// encodePage menghasilkan isi file halaman (belum dienkripsi) dan MIME-nya.
+func encodePage(profile string, settings ImageSettings, page importPage) ([]byte, string, error) {
+	if keepOriginal(profile, page) {
+		return readOriginalPage(page)
+	}
+
+	img, err := page.decode()
+	if err != nil {
+		return nil, "", fmt.Errorf("gagal decode: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if profile == storageLossless || profile == storageOriginal {
+		if profile == storageLossless {
+			img = settings.Lossless.fit(img)
+		}
+		if err := imaging.Encode(&buf, img, imaging.PNG); err != nil {
+			return nil, "", fmt.Errorf("gagal encode: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	img = settings.Compressed.fit(img)
+	if err := imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(settings.Compressed.Quality)); err != nil {
+		return nil, "", fmt.Errorf("gagal encode: %w", err)
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// keepOriginal: halaman disimpan sebagai file aslinya.
+func keepOriginal(profile string, page importPage) bool {
+	if page.Decode != nil || page.Format == nil || !page.Format.Web {
+		return false
+	}
+	return profile == storageOriginal || page.Animated
+}
+
+// readOriginalPage membaca file sumber utuh. Isinya tetap dicek bisa
+// dibaca sebagai gambar, supaya file rusak tidak ikut masuk vault.
+func readOriginalPage(page importPage) ([]byte, string, error) {
+	r, err := page.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("gagal membaca: %w", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, maxArchiveEntrySize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("gagal membaca: %w", err)
+	}
+	if len(data) > maxArchiveEntrySize {
+		return nil, "", fmt.Errorf("file terlalu besar")
+	}
+	if _, err := page.Format.readConfig(data); err != nil {
+		return nil, "", fmt.Errorf("gagal decode: %w", err)
+	}
+	return data, page.Format.MIME, nil
+}
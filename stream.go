@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// --- STREAMING ENCRYPTION (CONTAINER v2) ---
+//
+// Konstruksi STREAM: plaintext dipotong per segmen (default 64 KiB), tiap
+// segmen di-seal dengan nonce = noncePrefix(7) || counter(4) || lastFlag(1).
+// Header file menjadi AAD untuk semua segmen, dan flag "last" mencegah
+// file dipotong (truncation) tanpa ketahuan.
+//
+// Karena ukuran tiap segmen tetap, decryptReader bisa langsung lompat ke
+// segmen mana pun -> Range request tidak perlu membaca seluruh file.
+
+const (
+	streamChunkSize = 64 * 1024
+	noncePrefixSize = 7
+	gcmTagSize      = 16
+)
+
+// errNotStreaming: file valid tapi bukan format v2 (pakai jalur lama)
+var errNotStreaming = errors.New("bukan container streaming")
+
+func chunkNonce(prefix [noncePrefixSize]byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix[:])
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+func chunkCount(plainLen uint64, chunkSize uint32) uint64 {
+	if plainLen == 0 {
+		return 1 // selalu ada satu segmen terakhir (boleh kosong)
+	}
+	return (plainLen + uint64(chunkSize) - 1) / uint64(chunkSize)
+}
+
+// encryptWriter menulis container v2. Panjang plaintext harus diketahui di
+// awal karena tercatat di header (dan ikut diautentikasi).
+type encryptWriter struct {
+	dst      io.Writer
+	aead     cipher.AEAD
+	header   *FileHeader
+	aad      []byte
+	buf      []byte
+	counter  uint32
+	total    uint64
+	written  uint64
+	finished bool
+}
+
+func newEncryptWriter(dst io.Writer, key []byte, mime string, plainLen int64) (*encryptWriter, error) {
+	if key == nil {
+		return nil, ErrVaultLocked
+	}
+	if len(mime) > 255 {
+		return nil, fmt.Errorf("MIME type terlalu panjang")
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	h := &FileHeader{
+		Version:   containerV2,
+		Cipher:    cipherAES256GCM,
+		KeyID:     keyID(key),
+		MIME:      mime,
+		PlainLen:  uint64(plainLen),
+		ChunkSize: streamChunkSize,
+	}
+	if _, err := io.ReadFull(rand.Reader, h.NoncePrefix[:]); err != nil {
+		return nil, err
+	}
+	aad := h.marshal()
+	if _, err := dst.Write(aad); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		dst:    dst,
+		aead:   aead,
+		header: h,
+		aad:    aad,
+		buf:    make([]byte, 0, streamChunkSize),
+		total:  chunkCount(h.PlainLen, h.ChunkSize),
+	}, nil
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	if w.finished {
+		return 0, errors.New("encryptWriter sudah ditutup")
+	}
+	if w.written+uint64(len(p)) > w.header.PlainLen {
+		return 0, fmt.Errorf("data melebihi panjang yang dideklarasikan (%d byte)", w.header.PlainLen)
+	}
+	n := len(p)
+	for len(p) > 0 {
+		take := min(cap(w.buf)-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:take]...)
+		p = p[take:]
+		w.written += uint64(take)
+		// Segmen penuh yang BUKAN segmen terakhir langsung di-seal.
+		// Segmen terakhir menunggu Close supaya flag "last" benar.
+		if len(w.buf) == cap(w.buf) && uint64(w.counter)+1 < w.total {
+			if err := w.flush(false); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *encryptWriter) flush(last bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.header.NoncePrefix, w.counter, last), w.buf, w.aad)
+	if _, err := w.dst.Write(sealed); err != nil {
+		return err
+	}
+	w.counter++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Close menulis segmen terakhir. Tidak menutup dst.
+func (w *encryptWriter) Close() error {
+	if w.finished {
+		return nil
+	}
+	if w.written != w.header.PlainLen {
+		return fmt.Errorf("data kurang: %d dari %d byte", w.written, w.header.PlainLen)
+	}
+	w.finished = true
+	return w.flush(true)
+}
+
+// decryptReader mendekripsi container v2 secara acak (io.ReadSeeker).
+// Hanya satu segmen yang ada di memori pada satu waktu.
+type decryptReader struct {
+	src     io.ReaderAt
+	aead    cipher.AEAD
+	header  *FileHeader
+	aad     []byte
+	dataOff int64
+
+	pos      int64
+	chunkIdx int64
+	chunk    []byte
+}
+
+func newDecryptReader(src io.ReaderAt, size int64, keys ...[]byte) (*decryptReader, error) {
+	head := make([]byte, min(int64(maxHeaderSize), size))
+	if _, err := src.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	h, n, err := parseHeader(head)
+	if err != nil || h.Version < containerV2 {
+		return nil, errNotStreaming
+	}
+
+	chunks := chunkCount(h.PlainLen, h.ChunkSize)
+	if uint64(size) != uint64(n)+h.PlainLen+chunks*gcmTagSize {
+		return nil, ErrCorruptFile
+	}
+
+	for _, k := range keys {
+		if k == nil || keyID(k) != h.KeyID {
+			continue
+		}
+		aead, err := newGCM(k)
+		if err != nil {
+			return nil, err
+		}
+		return &decryptReader{
+			src:      src,
+			aead:     aead,
+			header:   h,
+			aad:      head[:n],
+			dataOff:  int64(n),
+			chunkIdx: -1,
+		}, nil
+	}
+	return nil, ErrWrongKey
+}
+
+func (d *decryptReader) loadChunk(idx int64) error {
+	if idx == d.chunkIdx {
+		return nil
+	}
+	cs := int64(d.header.ChunkSize)
+	plainLen := int64(d.header.PlainLen)
+	last := uint64(idx)+1 == chunkCount(d.header.PlainLen, d.header.ChunkSize)
+
+	size := min(cs, plainLen-idx*cs) + gcmTagSize
+	sealed := make([]byte, size)
+	if _, err := d.src.ReadAt(sealed, d.dataOff+idx*(cs+gcmTagSize)); err != nil && err != io.EOF {
+		return err
+	}
+	plain, err := d.aead.Open(sealed[:0], chunkNonce(d.header.NoncePrefix, uint32(idx), last), sealed, d.aad)
+	if err != nil {
+		return ErrCorruptFile
+	}
+	d.chunk = plain
+	d.chunkIdx = idx
+	return nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	if d.pos >= int64(d.header.PlainLen) {
+		return 0, io.EOF
+	}
+	cs := int64(d.header.ChunkSize)
+	idx := d.pos / cs
+	if err := d.loadChunk(idx); err != nil {
+		return 0, err
+	}
+	n := copy(p, d.chunk[d.pos-idx*cs:])
+	d.pos += int64(n)
+	return n, nil
+}
+
+func (d *decryptReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = d.pos + offset
+	case io.SeekEnd:
+		abs = int64(d.header.PlainLen) + offset
+	default:
+		return 0, errors.New("whence tidak valid")
+	}
+	if abs < 0 {
+		return 0, errors.New("posisi negatif")
+	}
+	d.pos = abs
+	return abs, nil
+}
+
+// writeEncryptedFile mengenkripsi isi src (sepanjang size byte) ke path
+// secara atomic: tulis ke file sementara, lalu rename.
+func writeEncryptedFile(path string, key []byte, mime string, size int64, src io.Reader) error {
+	return writeFileAtomicFunc(path, func(f io.Writer) error {
+		w, err := newEncryptWriter(f, key, mime, size)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, src); err != nil {
+			return err
+		}
+		return w.Close()
+	})
+}
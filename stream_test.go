@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := newDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(b)
+	return b
+}
+
+// decryptAll membuka container v2 dan membaca seluruh isinya.
+func decryptAll(data []byte, keys ...[]byte) ([]byte, error) {
+	dr, err := newDecryptReader(bytes.NewReader(data), int64(len(data)), keys...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(dr)
+}
+
+// sealedChunks memisahkan container menjadi header dan segmen-segmennya.
+func sealedChunks(t *testing.T, data []byte) ([]byte, [][]byte) {
+	t.Helper()
+	h, n, err := parseHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks [][]byte
+	rest := data[n:]
+	for len(rest) > 0 {
+		size := min(len(rest), int(h.ChunkSize)+gcmTagSize)
+		chunks = append(chunks, rest[:size])
+		rest = rest[size:]
+	}
+	return data[:n], chunks
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	key := testKey(t)
+	for _, n := range []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 17} {
+		plain := randomBytes(n)
+		data, err := EncryptData(key, plain, "image/png")
+		if err != nil {
+			t.Fatalf("%d byte: %v", n, err)
+		}
+		got, err := decryptAll(data, testKey(t), key)
+		if err != nil {
+			t.Fatalf("%d byte: %v", n, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Fatalf("%d byte: isi berbeda setelah dekripsi", n)
+		}
+		got, h, err := DecryptData(data, key)
+		if err != nil || !bytes.Equal(got, plain) || h.MIME != "image/png" {
+			t.Fatalf("%d byte: DecryptData = %v, %v", n, h, err)
+		}
+	}
+}
+
+func TestStreamSeek(t *testing.T) {
+	key := testKey(t)
+	plain := randomBytes(3*streamChunkSize + 100)
+	data, err := EncryptData(key, plain, "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr, err := newDecryptReader(bytes.NewReader(data), int64(len(data)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Range lintas batas segmen, mundur, dan di ujung file
+	for _, r := range [][2]int{
+		{streamChunkSize - 10, 20},
+		{2*streamChunkSize + 5, streamChunkSize},
+		{0, 10},
+		{len(plain) - 50, 50},
+	} {
+		if _, err := dr.Seek(int64(r[0]), io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, r[1])
+		if _, err := io.ReadFull(dr, buf); err != nil {
+			t.Fatalf("range %v: %v", r, err)
+		}
+		if !bytes.Equal(buf, plain[r[0]:r[0]+r[1]]) {
+			t.Fatalf("range %v: isi berbeda", r)
+		}
+	}
+	if pos, _ := dr.Seek(0, io.SeekEnd); pos != int64(len(plain)) {
+		t.Fatalf("SeekEnd = %d", pos)
+	}
+	if n, err := dr.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("Read di EOF = %d, %v", n, err)
+	}
+}
+
+func TestStreamTamper(t *testing.T) {
+	key := testKey(t)
+	plain := randomBytes(3*streamChunkSize + 100)
+	data, err := EncryptData(key, plain, "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, chunks := sealedChunks(t, data)
+	if len(chunks) != 4 {
+		t.Fatalf("jumlah segmen = %d, want 4", len(chunks))
+	}
+	join := func(head []byte, chunks ...[]byte) []byte {
+		return bytes.Join(append([][]byte{head}, chunks...), nil)
+	}
+	flip := func(data []byte, i int) []byte {
+		out := bytes.Clone(data)
+		out[i] ^= 0x01
+		return out
+	}
+
+	// Terpotong di batas segmen, PlainLen di header ikut disesuaikan supaya
+	// ukurannya konsisten (header = AAD semua segmen, jadi tetap gagal)
+	h, _, _ := parseHeader(data)
+	h.PlainLen = 3 * streamChunkSize
+	truncatedHead := h.marshal()
+
+	tests := []struct {
+		name string
+		data []byte
+		keys [][]byte
+		want error
+	}{
+		{"segmen terakhir dibuang", join(head, chunks[:3]...), [][]byte{key}, ErrCorruptFile},
+		{"terpotong di tengah segmen", data[:len(data)-10], [][]byte{key}, ErrCorruptFile},
+		{"terpotong, header disesuaikan", join(truncatedHead, chunks[:3]...), [][]byte{key}, ErrCorruptFile},
+		{"byte tambahan", append(bytes.Clone(data), 0), [][]byte{key}, ErrCorruptFile},
+		{"segmen ditukar", join(head, chunks[1], chunks[0], chunks[2], chunks[3]), [][]byte{key}, ErrCorruptFile},
+		{"ciphertext diubah", flip(data, len(head)+streamChunkSize+gcmTagSize+5), [][]byte{key}, ErrCorruptFile},
+		{"tag diubah", flip(data, len(data)-1), [][]byte{key}, ErrCorruptFile},
+		{"MIME di header diubah", flip(data, bytes.Index(head, []byte("image/jpeg"))), [][]byte{key}, ErrCorruptFile},
+		{"kunci salah", data, [][]byte{testKey(t)}, ErrWrongKey},
+		{"tanpa kunci", data, [][]byte{nil}, ErrWrongKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decryptAll(tt.data, tt.keys...); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if plain, _, err := DecryptData(tt.data, tt.keys...); !errors.Is(err, tt.want) || plain != nil {
+				t.Fatalf("DecryptData = %d byte, %v; want %v", len(plain), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptWriterLength(t *testing.T) {
+	key := testKey(t)
+	var buf bytes.Buffer
+	w, err := newEncryptWriter(&buf, key, "image/png", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(make([]byte, 11)); err == nil {
+		t.Fatal("Write melebihi panjang header tidak ditolak")
+	}
+	w.Write(make([]byte, 5))
+	if err := w.Close(); err == nil {
+		t.Fatal("Close dengan data kurang tidak ditolak")
+	}
+	if _, err := newEncryptWriter(&buf, nil, "image/png", 0); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("kunci nil: err = %v", err)
+	}
+}
+
+func TestWriteEncryptedFile(t *testing.T) {
+	key := testKey(t)
+	plain := randomBytes(2*streamChunkSize + 1)
+	path := filepath.Join(t.TempDir(), "page.jpg")
+	if err := writeEncryptedFile(path, key, "image/jpeg", int64(len(plain)), bytes.NewReader(plain)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + tmpFileSuffix); !os.IsNotExist(err) {
+		t.Fatal("file sementara tertinggal")
+	}
+	vf, err := openVaultFile(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vf.Close()
+	got, err := io.ReadAll(vf)
+	if err != nil || !bytes.Equal(got, plain) || vf.Header.MIME != "image/jpeg" {
+		t.Fatalf("openVaultFile: %v", err)
+	}
+
+	// Sumber lebih pendek dari size: file tujuan tidak boleh tertimpa
+	if err := writeEncryptedFile(path, key, "image/jpeg", 100, bytes.NewReader(plain[:10])); err == nil {
+		t.Fatal("sumber kurang tidak ditolak")
+	}
+	if data, _ := os.ReadFile(path); data == nil {
+		t.Fatal("file lama hilang")
+	} else if got, _, err := DecryptData(data, key); err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("file lama rusak: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- CACHE THUMBNAIL TERENKRIPSI ---
+//
+// Thumbnail cover disimpan di GalleryVault/cache dengan format container
+// yang sama seperti halaman buku (kunci vault, atau kunci buku untuk buku
+// terkunci). Nama file = hash(ID buku + CoverPath + pengaturan thumbnail),
+// jadi mengganti cover otomatis membuat entry lama tidak terpakai (dan
+// langsung dihapus oleh SetBookCover). Entry yang gagal didekripsi (misal setelah rotasi kunci)
+// dianggap tidak ada dan dibuat ulang.
+
+const thumbnailExt = ".thumb"
+
+func (a *App) thumbnailDir() string {
+	return filepath.Join(a.profileDir(), "cache")
+}
+
+func (a *App) thumbnailPath(book *Book) string {
+	spec := a.GetImageSettings().thumbSpec()
+	name := HashString(fmt.Sprintf("%d:%s:%s", book.ID, book.CoverPath, spec))
+	return filepath.Join(a.thumbnailDir(), name+thumbnailExt)
+}
+
+// loadThumbnail mengembalikan thumbnail dari cache beserta MIME-nya
+// (ok = false jika miss).
+func (a *App) loadThumbnail(book *Book) ([]byte, string, bool) {
+	data, err := os.ReadFile(a.thumbnailPath(book))
+	if err != nil {
+		return nil, "", false
+	}
+	keys := a.bookReadKeys(book)
+	defer wipeKeys(keys)
+	plain, header, err := DecryptData(data, keys...)
+	if err != nil {
+		return nil, "", false
+	}
+	return plain, header.MIME, true
+}
+
+func (a *App) storeThumbnail(book *Book, thumb []byte, mime string) error {
+	key, err := a.bookWriteKey(book)
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(key)
+	data, err := EncryptData(key, thumb, mime)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.thumbnailDir(), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(a.thumbnailPath(book), data)
+}
+
+func (a *App) dropThumbnail(book *Book) {
+	secureRemove(a.thumbnailPath(book))
+}
+
+// ClearThumbnailCache menghapus semua thumbnail (akan dibuat ulang saat dibuka).
+func (a *App) ClearThumbnailCache() error {
+	entries, err := os.ReadDir(a.thumbnailDir())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := secureRemove(filepath.Join(a.thumbnailDir(), e.Name())); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file cache gagal dihapus", failed)
+	}
+	return nil
+}
+
+// purgePlainThumbnails menghapus cache JPEG polos dari versi lama.
+func (a *App) purgePlainThumbnails() {
+	entries, _ := os.ReadDir(a.thumbnailDir())
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(strings.ToLower(e.Name()), ".jpg") {
+			secureRemove(filepath.Join(a.thumbnailDir(), e.Name()))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// --- SECURE DELETE & PANIC ---
+//
+// secureRemove menimpa isi file dengan data acak (lalu fsync) sebelum
+// di-unlink, supaya ciphertext lama tidak bisa di-recover dari sektor disk.
+// Catatan: di SSD (wear leveling) / filesystem copy-on-write ini tidak
+// bisa dijamin 100%, tapi tetap jauh lebih baik dari os.Remove biasa.
+
+func secureRemove(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	stat, err := f.Stat()
+	if err == nil {
+		_, err = io.CopyN(f, rand.Reader, stat.Size())
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// secureRemoveAll: secureRemove untuk semua file di dalam dir, lalu hapus foldernya.
+func secureRemoveAll(dir string) error {
+	var failed int
+	var lastErr error
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if err := secureRemove(path); err != nil {
+			failed++
+			lastErr = err
+		}
+		return nil
+	})
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file gagal ditimpa: %w", failed, lastErr)
+	}
+	return nil
+}
+
+// Panic mengunci semuanya saat itu juga (dipanggil dari hotkey frontend):
+// kunci vault & buku dihapus dari memori, Hidden Zone mati, cache thumbnail
+// semua profil dihapus, rotasi kunci yang berjalan dihentikan. Jika
+// destroyKey, key slot profil yang terakhir dibuka ikut dihapus sehingga
+// isinya tidak bisa dibuka lagi, bahkan dengan password benar.
+func (a *App) Panic(destroyKey bool) error {
+	if a.ctx != nil {
+		wailsRuntime.WindowMinimise(a.ctx)
+	}
+	if destroyKey {
+		a.audit(auditPanic, "master", true, "destroy_key")
+	} else {
+		a.audit(auditPanic, "master", true, "")
+	}
+	// Tidak menunggu proses lain (import/rotasi): keamanan lebih dulu
+	a.lockSession("panic")
+
+	var errs []error
+	for _, dir := range []string{
+		filepath.Join(a.appDataDir, "cache"),
+		filepath.Join(a.appDataDir, decoyDir, "cache"),
+	} {
+		if err := secureRemoveAll(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if destroyKey {
+		if err := a.destroyVaultKey(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		log.Printf("panic: %v", errs)
+		return fmt.Errorf("panic selesai dengan error: %v", errs[0])
+	}
+	return nil
+}
+
+// destroyVaultKey menghapus key slot (DEK terbungkus) profil yang terakhir
+// dibuka beserta hash password-nya, lalu VACUUM supaya datanya tidak
+// tersisa di library.db. Tanpa DEK, library.vault dan isi folder vault
+// profil itu hanya sampah acak, jadi keduanya langsung dibuang. Profil lain
+// (asli / decoy) tidak disentuh.
+func (a *App) destroyVaultKey() error {
+	var keys []string
+	for _, k := range []string{
+		"master_keyslot", "rotation_keyslot", "master_hash", "legacy_vault", "hidden_hash",
+		"recovery_pub", "recovery_box", "recovery_rotation_box",
+	} {
+		keys = append(keys, a.cfg(k))
+	}
+	a.configDB.Exec("PRAGMA secure_delete = ON")
+	if err := a.configDB.Where("key IN ?", keys).Delete(&GlobalConfig{}).Error; err != nil {
+		return err
+	}
+	if err := a.configDB.Exec("VACUUM").Error; err != nil {
+		return err
+	}
+
+	dir := a.profileDir()
+	if err := secureRemove(filepath.Join(dir, "library.vault")); err != nil {
+		return err
+	}
+	os.RemoveAll(filepath.Join(dir, "staging"))
+	return os.RemoveAll(filepath.Join(dir, "vault"))
+}